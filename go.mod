@@ -5,8 +5,9 @@ go 1.15
 require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/prometheus/client_golang v1.9.0
-	github.com/slack-go/slack v0.8.0
+	github.com/slack-go/slack v0.12.2
 	k8s.io/api v0.17.16
 	k8s.io/apimachinery v0.17.16
 	k8s.io/client-go v0.17.16
+	sigs.k8s.io/yaml v1.1.0
 )
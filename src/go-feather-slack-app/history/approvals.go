@@ -0,0 +1,252 @@
+/**
+ * File              : approvals.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package jobHistory
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	//ApprovalStoreLabel marks the objects holding pending approvals, its value is the name of the history object.
+	ApprovalStoreLabel = "go-feather-slack-app/approval-store"
+	approvalKey        = "approval.json"
+)
+
+var ErrApprovalNotFound = errors.New("This request has already been handled or has expired")
+
+//Approval is a job launch waiting for the approval of another authorized user.
+//The request and the job are kept as encoded by the server so any replica can launch it once approved.
+type Approval struct {
+	ID        string          `json:"id"`
+	Request   json.RawMessage `json:"request"`
+	Payload   json.RawMessage `json:"payload"`
+	ChannelID string          `json:"channelId"`
+	MessageTs string          `json:"messageTs"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+//ApprovalStore persists the pending approvals until they are approved, rejected or expired.
+type ApprovalStore interface {
+	//Add stores a new pending approval.
+	Add(approval *Approval) error
+	//Get returns a pending approval, ErrApprovalNotFound if it has been handled or does not exist.
+	Get(id string) (*Approval, error)
+	//Take removes a pending approval and returns it, only one caller takes it, the others get ErrApprovalNotFound.
+	Take(id string) (*Approval, error)
+	//List returns every pending approval, the first to expire first.
+	List() ([]*Approval, error)
+}
+
+//NewApprovalStore creates the ApprovalStore matching the history backend of config.
+//Approvals are kept in memory with the file backend, in one object per approval next to the history object otherwise.
+//@returns (ApprovalStore, error): the store, error if the backend is unknown.
+func NewApprovalStore(config Config, client kubernetes.Interface) (ApprovalStore, error) {
+	config = config.withDefaults()
+	switch config.Backend {
+	case "", FileBackend:
+		return NewMemoryApprovalStore(), nil
+	case ConfigMapBackend, SecretBackend:
+		return NewKubernetesApprovalStore(client, config.Backend, config.Namespace, config.Name), nil
+	default:
+		return nil, errors.New("Unknown history backend " + config.Backend)
+	}
+}
+
+//MemoryApprovalStore keeps the approvals in memory, it is meant for single replica installations.
+type MemoryApprovalStore struct {
+	mutex   sync.Mutex
+	pending map[string]*Approval
+}
+
+func NewMemoryApprovalStore() *MemoryApprovalStore {
+	return &MemoryApprovalStore{pending: make(map[string]*Approval)}
+}
+
+func (self *MemoryApprovalStore) Add(approval *Approval) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	approvalCopy := *approval
+	self.pending[approval.ID] = &approvalCopy
+	return nil
+}
+
+func (self *MemoryApprovalStore) Get(id string) (*Approval, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	approval, ok := self.pending[id]
+	if !ok {
+		return nil, ErrApprovalNotFound
+	}
+	approvalCopy := *approval
+	return &approvalCopy, nil
+}
+
+func (self *MemoryApprovalStore) Take(id string) (*Approval, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	approval, ok := self.pending[id]
+	if !ok {
+		return nil, ErrApprovalNotFound
+	}
+	delete(self.pending, id)
+	return approval, nil
+}
+
+func (self *MemoryApprovalStore) List() ([]*Approval, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	approvals := make([]*Approval, 0, len(self.pending))
+	for _, approval := range self.pending {
+		approvalCopy := *approval
+		approvals = append(approvals, &approvalCopy)
+	}
+	sortApprovals(approvals)
+	return approvals, nil
+}
+
+//KubernetesApprovalStore keeps each approval in its own ConfigMap or Secret, labeled with the history object name.
+//Taking an approval deletes its object, so a single replica handles it.
+type KubernetesApprovalStore struct {
+	client    kubernetes.Interface
+	kind      string
+	namespace string
+	name      string
+}
+
+//NewKubernetesApprovalStore creates a store of ConfigMaps (kind configmap) or Secrets (kind secret) named after the history object name.
+func NewKubernetesApprovalStore(client kubernetes.Interface, kind string, namespace string, name string) *KubernetesApprovalStore {
+	return &KubernetesApprovalStore{client: client, kind: kind, namespace: namespace, name: name}
+}
+
+func (self *KubernetesApprovalStore) Add(approval *Approval) error {
+	content, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+
+	objectMeta := metav1.ObjectMeta{Name: self.objectName(approval.ID), Namespace: self.namespace, Labels: map[string]string{ApprovalStoreLabel: self.name}}
+	if self.kind == SecretBackend {
+		_, err = self.client.CoreV1().Secrets(self.namespace).Create(&v1.Secret{ObjectMeta: objectMeta, Data: map[string][]byte{approvalKey: content}})
+	} else {
+		_, err = self.client.CoreV1().ConfigMaps(self.namespace).Create(&v1.ConfigMap{ObjectMeta: objectMeta, Data: map[string]string{approvalKey: string(content)}})
+	}
+	return err
+}
+
+func (self *KubernetesApprovalStore) Get(id string) (*Approval, error) {
+	approval, _, err := self.read(id)
+	return approval, err
+}
+
+func (self *KubernetesApprovalStore) Take(id string) (*Approval, error) {
+	approval, uid, err := self.read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	//The UID precondition makes sure the object deleted is the one read, another replica taking it first makes it fail.
+	options := &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}}
+	if self.kind == SecretBackend {
+		err = self.client.CoreV1().Secrets(self.namespace).Delete(self.objectName(id), options)
+	} else {
+		err = self.client.CoreV1().ConfigMaps(self.namespace).Delete(self.objectName(id), options)
+	}
+	if apiErrors.IsNotFound(err) || apiErrors.IsConflict(err) {
+		return nil, ErrApprovalNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+func (self *KubernetesApprovalStore) List() ([]*Approval, error) {
+	listOptions := metav1.ListOptions{LabelSelector: ApprovalStoreLabel + "=" + self.name}
+	contents := [][]byte{}
+	if self.kind == SecretBackend {
+		secrets, err := self.client.CoreV1().Secrets(self.namespace).List(listOptions)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets.Items {
+			contents = append(contents, secret.Data[approvalKey])
+		}
+	} else {
+		configMaps, err := self.client.CoreV1().ConfigMaps(self.namespace).List(listOptions)
+		if err != nil {
+			return nil, err
+		}
+		for _, configMap := range configMaps.Items {
+			contents = append(contents, []byte(configMap.Data[approvalKey]))
+		}
+	}
+
+	approvals := make([]*Approval, 0, len(contents))
+	for _, content := range contents {
+		approval := &Approval{}
+		if err := json.Unmarshal(content, approval); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	sortApprovals(approvals)
+	return approvals, nil
+}
+
+//read returns the approval id and the UID of its object, ErrApprovalNotFound if the object does not exist.
+func (self *KubernetesApprovalStore) read(id string) (*Approval, types.UID, error) {
+	var content []byte
+	var uid types.UID
+	if self.kind == SecretBackend {
+		secret, err := self.client.CoreV1().Secrets(self.namespace).Get(self.objectName(id), metav1.GetOptions{})
+		if apiErrors.IsNotFound(err) {
+			return nil, "", ErrApprovalNotFound
+		} else if err != nil {
+			return nil, "", err
+		}
+		content, uid = secret.Data[approvalKey], secret.UID
+	} else {
+		configMap, err := self.client.CoreV1().ConfigMaps(self.namespace).Get(self.objectName(id), metav1.GetOptions{})
+		if apiErrors.IsNotFound(err) {
+			return nil, "", ErrApprovalNotFound
+		} else if err != nil {
+			return nil, "", err
+		}
+		content, uid = []byte(configMap.Data[approvalKey]), configMap.UID
+	}
+
+	approval := &Approval{}
+	if err := json.Unmarshal(content, approval); err != nil {
+		return nil, "", err
+	}
+	return approval, uid, nil
+}
+
+func (self *KubernetesApprovalStore) objectName(id string) string {
+	return self.name + "-approval-" + id
+}
+
+//sortApprovals sorts approvals from the first to expire to the last.
+func sortApprovals(approvals []*Approval) {
+	sort.SliceStable(approvals, func(i, j int) bool {
+		return approvals[i].ExpiresAt.Before(approvals[j].ExpiresAt)
+	})
+}
@@ -0,0 +1,66 @@
+/**
+ * File              : approvals_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package jobHistory
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApprovalStores(t *testing.T) {
+	stores := map[string]ApprovalStore{
+		"memory":         NewMemoryApprovalStore(),
+		ConfigMapBackend: NewKubernetesApprovalStore(fake.NewSimpleClientset(), ConfigMapBackend, "default", "history"),
+		SecretBackend:    NewKubernetesApprovalStore(fake.NewSimpleClientset(), SecretBackend, "default", "history"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			expiresAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+			later := &Approval{ID: "later", Request: json.RawMessage(`{"UserID":"U1"}`), Payload: json.RawMessage(`{"kind":"migration"}`), ChannelID: "C0ANSWER", MessageTs: "1.2", ExpiresAt: expiresAt.Add(time.Minute)}
+			first := &Approval{ID: "first", Request: json.RawMessage(`{"UserID":"U2"}`), Payload: json.RawMessage(`{"kind":"seed"}`), ChannelID: "C0ANSWER", MessageTs: "1.3", ExpiresAt: expiresAt}
+			for _, approval := range []*Approval{later, first} {
+				if err := store.Add(approval); err != nil {
+					t.Fatalf("Add of %s failed : %s", approval.ID, err.Error())
+				}
+			}
+
+			approval, err := store.Get("later")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if approval.MessageTs != later.MessageTs || !approval.ExpiresAt.Equal(later.ExpiresAt) || string(approval.Payload) != string(later.Payload) {
+				t.Errorf("Expected %+v, got %+v", later, approval)
+			}
+
+			approvals, err := store.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(approvals) != 2 || approvals[0].ID != "first" || approvals[1].ID != "later" {
+				t.Errorf("Expected the approvals sorted by expiration, got %d approvals", len(approvals))
+			}
+
+			if approval, err := store.Take("later"); err != nil || approval.ID != "later" {
+				t.Fatalf("Expected to take later, got %v", err)
+			}
+			if _, err := store.Take("later"); err != ErrApprovalNotFound {
+				t.Errorf("Expected a taken approval not to be taken again, got %v", err)
+			}
+			if _, err := store.Get("later"); err != ErrApprovalNotFound {
+				t.Errorf("Expected a taken approval to be gone, got %v", err)
+			}
+			if approvals, _ := store.List(); len(approvals) != 1 {
+				t.Errorf("Expected 1 pending approval, got %d", len(approvals))
+			}
+		})
+	}
+}
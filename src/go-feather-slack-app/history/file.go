@@ -0,0 +1,116 @@
+/**
+ * File              : file.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package jobHistory
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//FileStore keeps the records in a local json file, it is meant for single replica installations.
+type FileStore struct {
+	mutex      sync.Mutex
+	path       string
+	maxRecords int
+	records    []*Record
+}
+
+func NewFileStore(path string, maxRecords int) (*FileStore, error) {
+	store := &FileStore{path: path, maxRecords: maxRecords}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &store.records); err != nil {
+		return nil, err
+	}
+	sortRecords(store.records)
+	return store, nil
+}
+
+//Save keeps a copy of record, so the caller can keep updating it.
+func (self *FileStore) Save(record *Record) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	recordCopy := *record
+	record = &recordCopy
+
+	replaced := false
+	for index, current := range self.records {
+		if current.ID == record.ID {
+			self.records[index] = record
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		self.records = append(self.records, record)
+	}
+
+	sortRecords(self.records)
+	if len(self.records) > self.maxRecords {
+		self.records = self.records[:self.maxRecords]
+	}
+	return self.write()
+}
+
+func (self *FileStore) Get(id string) (*Record, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	for _, record := range self.records {
+		if record.ID == id {
+			recordCopy := *record
+			return &recordCopy, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (self *FileStore) List() ([]*Record, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	records := make([]*Record, len(self.records))
+	for index, record := range self.records {
+		recordCopy := *record
+		records[index] = &recordCopy
+	}
+	return records, nil
+}
+
+//write replaces the file content through a temporary file so it is never left half written.
+func (self *FileStore) write() error {
+	content, err := json.Marshal(self.records)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(self.path), filepath.Base(self.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), self.path)
+}
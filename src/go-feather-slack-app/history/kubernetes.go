@@ -0,0 +1,190 @@
+/**
+ * File              : kubernetes.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package jobHistory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	recordKeySuffix = ".json"
+	//maxObjectSize keeps the encoded records of the object under the 1 MiB limit of Kubernetes objects, metadata included.
+	maxObjectSize = 900 * 1024
+)
+
+//KubernetesStore keeps the records in a ConfigMap or a Secret, one key per record.
+//It can be shared between several replicas of the server.
+type KubernetesStore struct {
+	client     kubernetes.Interface
+	kind       string
+	namespace  string
+	name       string
+	maxRecords int
+}
+
+//NewKubernetesStore creates a store backed by the ConfigMap (kind configmap) or Secret (kind secret) namespace/name.
+func NewKubernetesStore(client kubernetes.Interface, kind string, namespace string, name string, maxRecords int) *KubernetesStore {
+	return &KubernetesStore{client: client, kind: kind, namespace: namespace, name: name, maxRecords: maxRecords}
+}
+
+func (self *KubernetesStore) Save(record *Record) error {
+	content, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return retry.OnError(retry.DefaultRetry, isConcurrentWrite, func() error {
+		data, resourceVersion, err := self.read()
+		if err != nil {
+			return err
+		}
+
+		data[record.ID+recordKeySuffix] = content
+		self.prune(data)
+		return self.write(data, resourceVersion)
+	})
+}
+
+func (self *KubernetesStore) Get(id string) (*Record, error) {
+	data, _, err := self.read()
+	if err != nil {
+		return nil, err
+	}
+
+	content, ok := data[id+recordKeySuffix]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	record := &Record{}
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (self *KubernetesStore) List() ([]*Record, error) {
+	data, _, err := self.read()
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecords(data)
+}
+
+//read returns the records of the object by key and the object resourceVersion, empty when it does not exist yet.
+func (self *KubernetesStore) read() (map[string][]byte, string, error) {
+	data := make(map[string][]byte)
+
+	if self.kind == SecretBackend {
+		secret, err := self.client.CoreV1().Secrets(self.namespace).Get(self.name, metav1.GetOptions{})
+		if apiErrors.IsNotFound(err) {
+			return data, "", nil
+		} else if err != nil {
+			return nil, "", err
+		}
+		for key, value := range secret.Data {
+			data[key] = value
+		}
+		return data, secret.ResourceVersion, nil
+	}
+
+	configMap, err := self.client.CoreV1().ConfigMaps(self.namespace).Get(self.name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		return data, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+	return data, configMap.ResourceVersion, nil
+}
+
+//write creates the object when resourceVersion is empty, updates it otherwise (failing on conflict).
+func (self *KubernetesStore) write(data map[string][]byte, resourceVersion string) error {
+	objectMeta := metav1.ObjectMeta{Name: self.name, Namespace: self.namespace, ResourceVersion: resourceVersion}
+	create := resourceVersion == ""
+
+	if self.kind == SecretBackend {
+		secret := &v1.Secret{ObjectMeta: objectMeta, Data: data}
+		var err error
+		if create {
+			_, err = self.client.CoreV1().Secrets(self.namespace).Create(secret)
+		} else {
+			_, err = self.client.CoreV1().Secrets(self.namespace).Update(secret)
+		}
+		return err
+	}
+
+	stringData := make(map[string]string, len(data))
+	for key, value := range data {
+		stringData[key] = string(value)
+	}
+	configMap := &v1.ConfigMap{ObjectMeta: objectMeta, Data: stringData}
+	var err error
+	if create {
+		_, err = self.client.CoreV1().ConfigMaps(self.namespace).Create(configMap)
+	} else {
+		_, err = self.client.CoreV1().ConfigMaps(self.namespace).Update(configMap)
+	}
+	return err
+}
+
+//prune removes the oldest records so the object keeps at most maxRecords records and stays under maxObjectSize.
+func (self *KubernetesStore) prune(data map[string][]byte) {
+	records, err := decodeRecords(data)
+	if err != nil {
+		return
+	}
+
+	size := 0
+	for index, record := range records {
+		key := record.ID + recordKeySuffix
+		size += len(key) + self.encodedSize(data[key])
+		if index >= self.maxRecords || size > maxObjectSize {
+			delete(data, key)
+		}
+	}
+}
+
+//encodedSize returns the size of a value once stored, Secret values are base64 encoded.
+func (self *KubernetesStore) encodedSize(value []byte) int {
+	if self.kind == SecretBackend {
+		return base64.StdEncoding.EncodedLen(len(value))
+	}
+	return len(value)
+}
+
+//isConcurrentWrite tells whether err is due to another replica writing the object at the same time.
+func isConcurrentWrite(err error) bool {
+	return apiErrors.IsConflict(err) || apiErrors.IsAlreadyExists(err)
+}
+
+func decodeRecords(data map[string][]byte) ([]*Record, error) {
+	records := make([]*Record, 0, len(data))
+	for key, content := range data {
+		if !strings.HasSuffix(key, recordKeySuffix) {
+			continue
+		}
+		record := &Record{}
+		if err := json.Unmarshal(content, record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sortRecords(records)
+	return records, nil
+}
@@ -0,0 +1,147 @@
+/**
+ * File              : kubernetes_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package jobHistory
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+//newTestRecord returns the record of the index-th run, later runs having higher indexes.
+func newTestRecord(index int, excerptSize int) *Record {
+	return &Record{
+		ID:         "run-" + strconv.Itoa(index),
+		Command:    "/migration prod v1.2.3 add-users",
+		StartedAt:  time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).Add(time.Duration(index) * time.Minute),
+		LogExcerpt: strings.Repeat("x", excerptSize),
+		Payload:    json.RawMessage(`{"kind":"migration","envVariables":{"MIGRATION_NAME":"add-users"}}`),
+	}
+}
+
+func encodeTestRecords(t *testing.T, count int, excerptSize int) map[string][]byte {
+	data := map[string][]byte{}
+	for index := 0; index < count; index++ {
+		record := newTestRecord(index, excerptSize)
+		content, err := json.Marshal(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[record.ID+recordKeySuffix] = content
+	}
+	return data
+}
+
+func TestKubernetesStorePrune(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		count      int
+		excerpt    int
+		maxRecords int
+		//wantKept is -1 when records are pruned by size, as many as fit in maxObjectSize are then kept.
+		wantKept int
+	}{
+		{name: "under both limits", kind: ConfigMapBackend, count: 10, excerpt: 100, maxRecords: 20, wantKept: 10},
+		{name: "at max records", kind: ConfigMapBackend, count: 20, excerpt: 100, maxRecords: 20, wantKept: 20},
+		{name: "over max records", kind: ConfigMapBackend, count: 30, excerpt: 100, maxRecords: 20, wantKept: 20},
+		{name: "over max size", kind: ConfigMapBackend, count: 500, excerpt: 3000, maxRecords: 500, wantKept: -1},
+		{name: "secret values are base64 encoded", kind: SecretBackend, count: 500, excerpt: 3000, maxRecords: 500, wantKept: -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewKubernetesStore(nil, test.kind, "default", "history", test.maxRecords)
+			data := encodeTestRecords(t, test.count, test.excerpt)
+			store.prune(data)
+
+			size := 0
+			for key, value := range data {
+				size += len(key) + store.encodedSize(value)
+			}
+			if size > maxObjectSize {
+				t.Errorf("Expected at most %d bytes, got %d", maxObjectSize, size)
+			}
+
+			kept := len(data)
+			if test.wantKept >= 0 && kept != test.wantKept {
+				t.Fatalf("Expected %d records to be kept, got %d", test.wantKept, kept)
+			}
+			if test.wantKept < 0 {
+				next := newTestRecord(test.count-kept-1, test.excerpt)
+				content, _ := json.Marshal(next)
+				if kept == test.count || size+len(next.ID+recordKeySuffix)+store.encodedSize(content) <= maxObjectSize {
+					t.Fatalf("Expected records to be pruned only once over %d bytes, %d records of %d bytes kept", maxObjectSize, kept, size)
+				}
+			}
+			for index := test.count - kept; index < test.count; index++ {
+				if _, ok := data["run-"+strconv.Itoa(index)+recordKeySuffix]; !ok {
+					t.Fatalf("Expected the most recent records to be kept, run-%d has been pruned", index)
+				}
+			}
+		})
+	}
+}
+
+func TestKubernetesStoreFill(t *testing.T) {
+	for _, kind := range []string{ConfigMapBackend, SecretBackend} {
+		t.Run(kind, func(t *testing.T) {
+			//The fake client does not set resourceVersion, the object is created beforehand so it is updated.
+			objectMeta := metav1.ObjectMeta{Name: "history", Namespace: "default", ResourceVersion: "1"}
+			client := fake.NewSimpleClientset(&v1.ConfigMap{ObjectMeta: objectMeta}, &v1.Secret{ObjectMeta: objectMeta})
+			store := NewKubernetesStore(client, kind, "default", "history", defaultMaxRecords)
+
+			count := defaultMaxRecords + 100
+			for index := 0; index < count; index++ {
+				if err := store.Save(newTestRecord(index, 3000)); err != nil {
+					t.Fatalf("Save of record %d failed : %s", index, err.Error())
+				}
+			}
+
+			size := 0
+			if kind == SecretBackend {
+				secret, err := client.CoreV1().Secrets("default").Get("history", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for key, value := range secret.Data {
+					size += len(key) + store.encodedSize(value)
+				}
+			} else {
+				configMap, err := client.CoreV1().ConfigMaps("default").Get("history", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for key, value := range configMap.Data {
+					size += len(key) + len(value)
+				}
+			}
+			if size > maxObjectSize {
+				t.Errorf("Expected at most %d bytes, got %d", maxObjectSize, size)
+			}
+
+			last := newTestRecord(count-1, 0)
+			if _, err := store.Get(last.ID); err != nil {
+				t.Errorf("Expected the last record to be kept : %s", err.Error())
+			}
+			records, err := store.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) == 0 || records[0].ID != last.ID {
+				t.Errorf("Expected the last record to be listed first")
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/**
+ * File              : store.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package jobHistory
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	FileBackend      = "file"
+	ConfigMapBackend = "configmap"
+	SecretBackend    = "secret"
+
+	defaultFilePath   = "go-feather-slack-app-history.json"
+	defaultObjectName = "go-feather-slack-app-history"
+	defaultMaxRecords = 500
+)
+
+//Phases of a run, the pod phases (Pending, Running, Succeeded, Failed) are used once its Job is created.
+const (
+	PhaseCreating  = "Creating"
+	PhaseSucceeded = "Succeeded"
+	PhaseFailed    = "Failed"
+	PhaseCancelled = "Cancelled"
+)
+
+var ErrNotFound = errors.New("This run does not exist in history")
+
+//Record describes a job run, from its request until its end.
+type Record struct {
+	ID              string          `json:"id"`
+	RequesterID     string          `json:"requesterId"`
+	RequesterName   string          `json:"requesterName"`
+	Command         string          `json:"command"`
+	Kind            string          `json:"kind"`
+	Environment     string          `json:"environment"`
+	Service         string          `json:"service"`
+	Version         string          `json:"version"`
+	Image           string          `json:"image"`
+	MigrationName   string          `json:"migrationName"`
+	Namespace       string          `json:"namespace"`
+	JobName         string          `json:"jobName"`
+	PodName         string          `json:"podName"`
+	Phase           string          `json:"phase"`
+	DeadlineSeconds int64           `json:"deadlineSeconds,omitempty"`
+	StartedAt       time.Time       `json:"startedAt"`
+	FinishedAt      *time.Time      `json:"finishedAt,omitempty"`
+	ExitCode        *int32          `json:"exitCode,omitempty"`
+	FailureReason   string          `json:"failureReason,omitempty"`
+	LogExcerpt      string          `json:"logExcerpt"`
+	ChannelID       string          `json:"channelId"`
+	ThreadTs        string          `json:"threadTs"`
+	RetryOf         string          `json:"retryOf,omitempty"`
+	CancelledBy     string          `json:"cancelledBy,omitempty"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+}
+
+//Store persists the job runs records.
+type Store interface {
+	//Save creates or replaces the record with the same ID.
+	Save(record *Record) error
+	//Get returns the record of a run, ErrNotFound if it does not exist.
+	Get(id string) (*Record, error)
+	//List returns every record, the most recent first.
+	List() ([]*Record, error)
+}
+
+//Config selects and configures the Store backend.
+type Config struct {
+	Backend    string `json:"backend"`
+	Path       string `json:"path"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	MaxRecords int    `json:"maxRecords"`
+}
+
+//withDefaults fills the missing settings with their defaults.
+func (self Config) withDefaults() Config {
+	if self.MaxRecords <= 0 {
+		self.MaxRecords = defaultMaxRecords
+	}
+	if self.Namespace == "" {
+		self.Namespace = "default"
+	}
+	if self.Name == "" {
+		self.Name = defaultObjectName
+	}
+	if self.Path == "" {
+		self.Path = defaultFilePath
+	}
+	return self
+}
+
+//New creates the Store described by config.
+//@args config: the history configuration, file backend is used by default
+//@args client: kubernetes client used by configmap and secret backends
+//@returns (Store, error): the store, error if the backend is unknown or can't be opened.
+func New(config Config, client kubernetes.Interface) (Store, error) {
+	config = config.withDefaults()
+	switch config.Backend {
+	case "", FileBackend:
+		return NewFileStore(config.Path, config.MaxRecords)
+	case ConfigMapBackend, SecretBackend:
+		return NewKubernetesStore(client, config.Backend, config.Namespace, config.Name, config.MaxRecords), nil
+	default:
+		return nil, errors.New("Unknown history backend " + config.Backend)
+	}
+}
+
+//sortRecords sorts records from the most recent to the oldest.
+func sortRecords(records []*Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].StartedAt.After(records[j].StartedAt)
+	})
+}
+
+//Filter selects records, zero values match everything.
+type Filter struct {
+	Kind          string
+	Environment   string
+	RequesterID   string
+	RequesterName string
+	Phase         string
+	Since         time.Time
+	Until         time.Time
+}
+
+//Match tells whether record satisfies every criteria of the filter.
+func (self *Filter) Match(record *Record) bool {
+	return (self.Kind == "" || record.Kind == self.Kind) &&
+		(self.Environment == "" || record.Environment == self.Environment) &&
+		(self.RequesterID == "" || record.RequesterID == self.RequesterID) &&
+		(self.RequesterName == "" || strings.EqualFold(record.RequesterName, self.RequesterName)) &&
+		(self.Phase == "" || strings.EqualFold(record.Phase, self.Phase)) &&
+		(self.Since.IsZero() || !record.StartedAt.Before(self.Since)) &&
+		(self.Until.IsZero() || record.StartedAt.Before(self.Until))
+}
+
+//FilterRecords returns the records matching filter, keeping their order.
+func FilterRecords(records []*Record, filter *Filter) []*Record {
+	filtered := make([]*Record, 0, len(records))
+	for _, record := range records {
+		if filter.Match(record) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,152 @@
+/**
+ * File              : diagnostics.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+//ContainerDiagnostic is the last known state of a container of a failed pod.
+type ContainerDiagnostic struct {
+	Name         string
+	RestartCount int32
+	//State is either Waiting, Running or Terminated.
+	State     string
+	Reason    string
+	Message   string
+	ExitCode  *int32
+	OOMKilled bool
+}
+
+//EventDiagnostic is a Warning event related to a pod or to its Job (FailedScheduling, ErrImagePull, BackOff ...).
+type EventDiagnostic struct {
+	Object   string
+	Reason   string
+	Message  string
+	Count    int32
+	LastSeen time.Time
+}
+
+//PodDiagnostics gathers what explains why a pod failed.
+type PodDiagnostics struct {
+	PodName    string
+	Phase      string
+	Reason     string
+	Message    string
+	Containers []ContainerDiagnostic
+	Events     []EventDiagnostic
+}
+
+//Summary sums up the diagnostics in a few words (eg. "OOMKilled (exit code 137)").
+func (self *PodDiagnostics) Summary() string {
+	for _, container := range self.Containers {
+		if container.Reason == "" || container.State == "Running" {
+			continue
+		}
+		summary := container.Reason
+		if container.ExitCode != nil {
+			summary += " (exit code " + strconv.Itoa(int(*container.ExitCode)) + ")"
+		}
+		return summary
+	}
+
+	if self.Reason != "" {
+		return self.Reason
+	}
+	if len(self.Events) > 0 {
+		return self.Events[len(self.Events)-1].Reason
+	}
+	return ""
+}
+
+//GetPodDiagnostics gathers the containers state of a pod and the Warning events of the pod and of its Job.
+//@returns (*PodDiagnostics, error): error if the pod can't be fetched, events failures are ignored.
+func (self *PodManager) GetPodDiagnostics(namespace string, podName string) (*PodDiagnostics, error) {
+	pod, err := self.GetPod(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnostics := &PodDiagnostics{
+		PodName: pod.Name,
+		Phase:   string(pod.Status.Phase),
+		Reason:  pod.Status.Reason,
+		Message: pod.Status.Message,
+	}
+
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		diagnostics.Containers = append(diagnostics.Containers, containerDiagnostic(status))
+	}
+
+	diagnostics.Events = self.getWarningEvents(namespace, "Pod", pod.Name)
+	if jobName := pod.Labels["job-name"]; jobName != "" {
+		diagnostics.Events = append(diagnostics.Events, self.getWarningEvents(namespace, "Job", jobName)...)
+	}
+	sort.Slice(diagnostics.Events, func(i, j int) bool {
+		return diagnostics.Events[i].LastSeen.Before(diagnostics.Events[j].LastSeen)
+	})
+	return diagnostics, nil
+}
+
+func containerDiagnostic(status v1.ContainerStatus) ContainerDiagnostic {
+	diagnostic := ContainerDiagnostic{Name: status.Name, RestartCount: status.RestartCount}
+	state := status.State
+	//A restarted container which is waiting again is explained by its previous termination.
+	if state.Terminated == nil && status.LastTerminationState.Terminated != nil && state.Running == nil {
+		state = status.LastTerminationState
+	}
+
+	switch {
+	case state.Terminated != nil:
+		exitCode := state.Terminated.ExitCode
+		diagnostic.State = "Terminated"
+		diagnostic.Reason = state.Terminated.Reason
+		diagnostic.Message = state.Terminated.Message
+		diagnostic.ExitCode = &exitCode
+		diagnostic.OOMKilled = state.Terminated.Reason == "OOMKilled"
+	case state.Waiting != nil:
+		diagnostic.State = "Waiting"
+		diagnostic.Reason = state.Waiting.Reason
+		diagnostic.Message = state.Waiting.Message
+	default:
+		diagnostic.State = "Running"
+	}
+	return diagnostic
+}
+
+func (self *PodManager) getWarningEvents(namespace string, kind string, name string) []EventDiagnostic {
+	selector := fields.Set{"involvedObject.kind": kind, "involvedObject.name": name, "type": v1.EventTypeWarning}.AsSelector().String()
+	events, err := self.client.CoreV1().Events(namespace).List(metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		log.Printf("Error when listing events of %s %s : %s", kind, name, err.Error())
+		return nil
+	}
+
+	diagnostics := make([]EventDiagnostic, 0, len(events.Items))
+	for _, event := range events.Items {
+		lastSeen := event.LastTimestamp.Time
+		if lastSeen.IsZero() {
+			lastSeen = event.EventTime.Time
+		}
+		diagnostics = append(diagnostics, EventDiagnostic{
+			Object:   kind + "/" + name,
+			Reason:   event.Reason,
+			Message:  event.Message,
+			Count:    event.Count,
+			LastSeen: lastSeen,
+		})
+	}
+	return diagnostics
+}
@@ -2,39 +2,303 @@
  * File              : helpers.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 28.12.2020
- * Last Modified Date: 18.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package podManager
 
 import (
+	"context"
 	"errors"
 	"log"
+	"regexp"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
 type PodManager struct {
-	client *kubernetes.Clientset
+	client kubernetes.Interface
 }
 
-type HandlerWaitingFunc func(watcher watch.Interface, pod *v1.Pod) error
+//Client returns the kubernetes client, to be shared with other kubernetes backed components.
+func (self *PodManager) Client() kubernetes.Interface {
+	return self.client
+}
+
+//JobSpecOptions holds the optional settings of the JobSpec built by CreateJobSpec.
+type JobSpecOptions struct {
+	ServiceAccountName string
+	//ActiveDeadlineSeconds kills the Job once it has run for this long, 0 for no deadline.
+	ActiveDeadlineSeconds int64
+	//BackoffLimit is the count of retries of a failed Job, 0 when nil.
+	BackoffLimit *int32
+	//TTLSecondsAfterFinished is how long an ended Job is kept, 120 when nil.
+	TTLSecondsAfterFinished *int32
+	//RestartPolicy of the pod, Never when empty.
+	RestartPolicy v1.RestartPolicy
+}
+
+//Labels and annotations set on Jobs so their run can be recovered after a restart.
+//Label values are sanitized to select the Jobs, the run values are kept as is in the annotations of the same name.
+const (
+	RunIDLabel          = "go-feather-slack-app/run-id"
+	ChannelLabel        = "go-feather-slack-app/channel"
+	ThreadTsLabel       = "go-feather-slack-app/thread-ts"
+	EnvironmentLabel    = "go-feather-slack-app/environment"
+	CommandAnnotation   = "go-feather-slack-app/command"
+	RequesterAnnotation = "go-feather-slack-app/requester"
+
+	//maxLabelValueSize is the longest label value accepted by Kubernetes.
+	maxLabelValueSize = 63
+)
+
+//invalidLabelValueCharacters are replaced in label values, Kubernetes only accepts alphanumerics, '-', '_' and '.'.
+var invalidLabelValueCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+//labelValue turns value into a valid label value, beginning and ending with an alphanumeric character.
+func labelValue(value string) string {
+	value = invalidLabelValueCharacters.ReplaceAllString(value, "-")
+	if len(value) > maxLabelValueSize {
+		value = value[:maxLabelValueSize]
+	}
+	return strings.Trim(value, "._-")
+}
+
+//RunInfo identifies the Slack run a Job belongs to.
+type RunInfo struct {
+	ID          string
+	ChannelID   string
+	ThreadTs    string
+	Environment string
+	Command     string
+	RequesterID string
+}
+
+//HandlerWaitingFunc is a wait strategy, it follows a pod through its watcher until waiting for it is over.
+//onUpdate (if not nil) is called on every pod change and every progressInterval.
+//@returns (string, error): the phase the pod ended with, or Succeeded when the strategy ends waiting before the pod ends.
+type HandlerWaitingFunc func(ctx context.Context, watcher watch.Interface, pod *v1.Pod, onUpdate PodUpdateFunc) (string, error)
+
+//PodUpdateFunc is called with the latest known state of a watched pod.
+type PodUpdateFunc func(pod *v1.Pod)
+
+//JobAttempt is a pod run by a Job, a Job retrying runs several attempts.
+type JobAttempt struct {
+	Number   int
+	PodName  string
+	Phase    string
+	ExitCode *int32
+	Logs     string
+	//Err is set when the pod could not be followed until its end.
+	Err error
+}
+
+//JobResult is the outcome of a Job, read from its Complete or Failed condition.
+type JobResult struct {
+	Phase    string
+	Reason   string
+	Message  string
+	Attempts []JobAttempt
+}
+
+//JobHandlers are called while following the attempts of a Job, any of them may be nil.
+type JobHandlers struct {
+	//WaitingFunc decides when an attempt is over, DefaultHandlerWaitingFunc when nil.
+	WaitingFunc    HandlerWaitingFunc
+	OnAttemptStart func(number int, pod *v1.Pod)
+	OnUpdate       PodUpdateFunc
+	OnLine         LogLineFunc
+	OnRestart      RestartFunc
+	OnAttemptEnd   func(attempt JobAttempt)
+}
+
+//LogLineFunc is called with every line of a followed pod logs.
+type LogLineFunc func(line string)
+
+//RestartFunc is called when the container of a followed pod restarts, with the logs of the previous container.
+type RestartFunc func(restartCount int32, previousLogs string)
+
+//maxLogLineSize is the longest log line FollowPodLogs can read.
+const maxLogLineSize = 1024 * 1024
+
+//progressInterval is the interval at which PodUpdateFunc is called when the pod does not change.
+const progressInterval = 30 * time.Second
+
+//podDiscoveryTimeout is how long WaitForJobPod waits for a pod to be created by a Job.
+const podDiscoveryTimeout = 2 * time.Minute
+
+//unschedulableTimeout is how long a pod may stay unschedulable before waiting for it is aborted.
+const unschedulableTimeout = 5 * time.Minute
+
+//fatalWaitingReasons are the waiting reasons of a container which will not start without someone fixing the Job.
+var fatalWaitingReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+//PodStartError is returned when waiting for a pod which will never start by itself
+//(mistyped image, missing ConfigMap, no node to run on).
+type PodStartError struct {
+	PodName string
+	Reason  string
+	Message string
+}
 
+func (self *PodStartError) Error() string {
+	message := "Pod " + self.PodName + " can't start : " + self.Reason
+	if self.Message != "" {
+		message += " (" + self.Message + ")"
+	}
+	return message
+}
+
+//checkPodStart looks for a reason preventing the pod from ever starting.
+//@returns error: a *PodStartError if the pod can't start, nil otherwise.
+func checkPodStart(pod *v1.Pod) error {
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if waiting := status.State.Waiting; waiting != nil && fatalWaitingReasons[waiting.Reason] {
+			return &PodStartError{PodName: pod.GetName(), Reason: waiting.Reason, Message: waiting.Message}
+		}
+	}
 
-func DefaultHandlerWaitingFunc(watcher watch.Interface, pod *v1.Pod) (string, error) {
-	var podPhase string
-	for event := range watcher.ResultChan() {
-		p, ok := event.Object.(*v1.Pod)
-		if !ok {
-			return "", errors.New("Unexpected type for *v1.Pod whithin watcher event loop")
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable &&
+			time.Since(condition.LastTransitionTime.Time) > unschedulableTimeout {
+			return &PodStartError{PodName: pod.GetName(), Reason: v1.PodReasonUnschedulable, Message: condition.Message}
 		}
-		log.Printf("Pod %s is in state %s", p.GetName(), string(p.Status.Phase))
-		podPhase = string(p.Status.Phase)
-		if podPhase == "Succeeded" || podPhase == "Failed" {
-			watcher.Stop()
+	}
+	return nil
+}
+
+//podDoneFunc tells if waiting for a pod is over, along with the phase to end with.
+type podDoneFunc func(pod *v1.Pod) (string, bool)
+
+//DefaultHandlerWaitingFunc waits until the pod is Succeeded or Failed.
+func DefaultHandlerWaitingFunc(ctx context.Context, watcher watch.Interface, pod *v1.Pod, onUpdate PodUpdateFunc) (string, error) {
+	return waitForPod(ctx, watcher, pod, onUpdate, podEnded, nil)
+}
+
+//WaitForPhase waits until the pod reaches one of phases (eg. Running), or ends.
+//Reaching a phase before the pod ends counts as Succeeded.
+func WaitForPhase(phases ...v1.PodPhase) HandlerWaitingFunc {
+	return func(ctx context.Context, watcher watch.Interface, pod *v1.Pod, onUpdate PodUpdateFunc) (string, error) {
+		return waitForPod(ctx, watcher, pod, onUpdate, func(p *v1.Pod) (string, bool) {
+			if phase, ended := podEnded(p); ended {
+				return phase, true
+			}
+			for _, phase := range phases {
+				if p.Status.Phase == phase {
+					return string(v1.PodSucceeded), true
+				}
+			}
+			return "", false
+		}, nil)
+	}
+}
+
+//WaitForLogLine waits until the pod prints a line matching pattern (eg. "No migrations were executed"), the pod is then considered Succeeded.
+//Waiting also ends when the pod ends without printing it.
+func (self *PodManager) WaitForLogLine(pattern *regexp.Regexp) HandlerWaitingFunc {
+	return func(ctx context.Context, watcher watch.Interface, pod *v1.Pod, onUpdate PodUpdateFunc) (string, error) {
+		followCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		matched := make(chan string, 1)
+		following := false
+		follow := func(p *v1.Pod) {
+			if following || !containerStarted(p) {
+				return
+			}
+			following = true
+			go self.FollowPodLogs(followCtx, p.GetNamespace(), p.GetName(), func(line string) {
+				if pattern.MatchString(line) {
+					select {
+					case matched <- string(v1.PodSucceeded):
+					default:
+					}
+				}
+			})
+		}
+
+		follow(pod)
+		return waitForPod(ctx, watcher, pod, func(p *v1.Pod) {
+			follow(p)
+			if onUpdate != nil {
+				onUpdate(p)
+			}
+		}, podEnded, matched)
+	}
+}
+
+//podEnded is done once the pod is Succeeded or Failed.
+func podEnded(pod *v1.Pod) (string, bool) {
+	phase := pod.Status.Phase
+	return string(phase), phase == v1.PodSucceeded || phase == v1.PodFailed
+}
+
+//waitForPod is the loop shared by wait strategies, the watcher is stopped when ctx is cancelled.
+//Waiting is over once done says so, or when stop receives the phase to end with.
+//onUpdate (if not nil) is called on every pod change and every progressInterval so elapsed times can be refreshed.
+//Waiting is aborted with a *PodStartError as soon as the pod can't start by itself.
+func waitForPod(ctx context.Context, watcher watch.Interface, pod *v1.Pod, onUpdate PodUpdateFunc, done podDoneFunc, stop <-chan string) (string, error) {
+	defer watcher.Stop()
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	podPhase := string(pod.Status.Phase)
+	lastPod := pod
+	if err := checkPodStart(pod); err != nil {
+		return podPhase, err
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if onUpdate != nil {
+				onUpdate(lastPod)
+			}
+			if err := checkPodStart(lastPod); err != nil {
+				return podPhase, err
+			}
+		case <-ctx.Done():
+			log.Printf("Stop waiting for pod %s : %s", pod.GetName(), ctx.Err().Error())
+			return podPhase, ctx.Err()
+		case phase := <-stop:
+			log.Printf("Stop waiting for pod %s, its wait strategy ended with %s", pod.GetName(), phase)
+			return phase, nil
+		case event, open := <-watcher.ResultChan():
+			if !open {
+				return podPhase, errors.New("Watching pod " + pod.GetName() + " has been interrupted")
+			}
+			if event.Type == watch.Error {
+				return podPhase, apierrors.FromObject(event.Object)
+			}
+			p, ok := event.Object.(*v1.Pod)
+			if !ok {
+				return "", errors.New("Unexpected type for *v1.Pod whithin watcher event loop")
+			}
+			if event.Type == watch.Deleted {
+				return podPhase, errors.New("Pod " + p.GetName() + " has been deleted")
+			}
+			log.Printf("Pod %s is in state %s", p.GetName(), string(p.Status.Phase))
+			podPhase = string(p.Status.Phase)
+			lastPod = p
+			if onUpdate != nil {
+				onUpdate(p)
+			}
+			if phase, isDone := done(p); isDone {
+				return phase, nil
+			}
+			if err := checkPodStart(p); err != nil {
+				return podPhase, err
+			}
 		}
 	}
-	return podPhase, nil
 }
@@ -0,0 +1,155 @@
+/**
+ * File              : helpers_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestLabelValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "prod", want: "prod"},
+		{value: "Prod_EU.1", want: "Prod_EU.1"},
+		{value: "prod eu", want: "prod-eu"},
+		{value: "préprod", want: "pr-prod"},
+		{value: " prod ", want: "prod"},
+		{value: "1718000000.123456", want: "1718000000.123456"},
+		{value: strings.Repeat("a", 70), want: strings.Repeat("a", 63)},
+		{value: strings.Repeat("a", 62) + " b", want: strings.Repeat("a", 62)},
+		{value: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if got := labelValue(test.value); got != test.want {
+				t.Errorf("Expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+//podInPhase returns the pod of the waitForPod tests in phase, its container waiting for reason if any.
+func podInPhase(phase v1.PodPhase, waitingReason string) *v1.Pod {
+	pod := newTestPod("job", "pod-1", 1)
+	pod.Status.Phase = phase
+	if waitingReason != "" {
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "job", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: waitingReason}}}}
+	}
+	return pod
+}
+
+func TestWaitForPod(t *testing.T) {
+	tests := []struct {
+		name       string
+		done       podDoneFunc
+		events     []watch.Event
+		closed     bool
+		stop       string
+		want       string
+		wantErr    bool
+		wantUpdate int
+	}{
+		{
+			name: "pod succeeded",
+			done: podEnded,
+			events: []watch.Event{
+				{Type: watch.Modified, Object: podInPhase(v1.PodRunning, "")},
+				{Type: watch.Modified, Object: podInPhase(v1.PodSucceeded, "")},
+			},
+			want:       "Succeeded",
+			wantUpdate: 2,
+		},
+		{
+			name:       "pod failed",
+			done:       podEnded,
+			events:     []watch.Event{{Type: watch.Modified, Object: podInPhase(v1.PodFailed, "")}},
+			want:       "Failed",
+			wantUpdate: 1,
+		},
+		{
+			name: "phase reached",
+			done: func(pod *v1.Pod) (string, bool) {
+				return "Succeeded", pod.Status.Phase == v1.PodRunning
+			},
+			events:     []watch.Event{{Type: watch.Modified, Object: podInPhase(v1.PodRunning, "")}},
+			want:       "Succeeded",
+			wantUpdate: 1,
+		},
+		{
+			name:    "pod deleted",
+			done:    podEnded,
+			events:  []watch.Event{{Type: watch.Deleted, Object: podInPhase(v1.PodRunning, "")}},
+			wantErr: true,
+		},
+		{
+			name:       "pod can't start",
+			done:       podEnded,
+			events:     []watch.Event{{Type: watch.Modified, Object: podInPhase(v1.PodPending, "ImagePullBackOff")}},
+			wantErr:    true,
+			wantUpdate: 1,
+		},
+		{
+			name:       "missing ConfigMap",
+			done:       podEnded,
+			events:     []watch.Event{{Type: watch.Modified, Object: podInPhase(v1.PodPending, "CreateContainerConfigError")}},
+			wantErr:    true,
+			wantUpdate: 1,
+		},
+		{
+			name:       "watch closed before the pod ended",
+			done:       podEnded,
+			events:     []watch.Event{{Type: watch.Modified, Object: podInPhase(v1.PodRunning, "")}},
+			closed:     true,
+			wantErr:    true,
+			wantUpdate: 1,
+		},
+		{
+			name: "stopped by the wait strategy",
+			done: podEnded,
+			stop: "Succeeded",
+			want: "Succeeded",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			watcher := sentWatcher(test.closed, test.events...)()
+			stop := make(chan string, 1)
+			if test.stop != "" {
+				stop <- test.stop
+			}
+			updates := 0
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			phase, err := waitForPod(ctx, watcher, podInPhase(v1.PodPending, ""), func(pod *v1.Pod) { updates++ }, test.done, stop)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expected error %v, got %v", test.wantErr, err)
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Expected waiting to end before the deadline")
+			}
+			if err == nil && phase != test.want {
+				t.Errorf("Expected phase %s, got %s", test.want, phase)
+			}
+			if updates != test.wantUpdate {
+				t.Errorf("Expected %d updates, got %d", test.wantUpdate, updates)
+			}
+		})
+	}
+}
@@ -2,31 +2,60 @@
  * File              : job.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 29.12.2020
- * Last Modified Date: 04.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package podManager
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
+// DeleteJob: deletes the Job with foreground propagation, so its pods are deleted before the Job itself.
 func (self *PodManager) DeleteJob(namespace string, jobName string) error {
 	log.Printf("Deleteing job %s on namespace %s", jobName, namespace)
-	if err := self.client.BatchV1().Jobs(namespace).Delete(jobName, &metav1.DeleteOptions{}); err != nil {
+	propagationPolicy := metav1.DeletePropagationForeground
+	if err := self.client.BatchV1().Jobs(namespace).Delete(jobName, &metav1.DeleteOptions{PropagationPolicy: &propagationPolicy}); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (self *PodManager) CreateJobSpec(jobNamePrefix string, containerName string, containerImage string, envs []v1.EnvVar, configMapRefs []v1.ConfigMapEnvSource) *batchv1.JobSpec {
+// GetJobFailureReason: returns the reason of the Failed condition of a Job (eg. DeadlineExceeded, BackoffLimitExceeded).
+//@returns (string, error): empty reason if the Job has not failed.
+func (self *PodManager) GetJobFailureReason(namespace string, jobName string) (string, error) {
+	job, err := self.client.BatchV1().Jobs(namespace).Get(jobName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	if phase, reason, _ := jobOutcome(job); phase == string(v1.PodFailed) {
+		return reason, nil
+	}
+	return "", nil
+}
+
+func (self *PodManager) CreateJobSpec(jobNamePrefix string, containerName string, containerImage string, envs []v1.EnvVar, configMapRefs []v1.ConfigMapEnvSource, options JobSpecOptions) *batchv1.JobSpec {
 	backOffLimit := int32(0)              //This is set to forbid 5 other pod to be created (default value: 6).
 	TTLSecondsAfterFinished := int32(120) // Set to let Job being automatically cleaned up.
+	restartPolicy := v1.RestartPolicyNever
+	if options.BackoffLimit != nil {
+		backOffLimit = *options.BackoffLimit
+	}
+	if options.TTLSecondsAfterFinished != nil {
+		TTLSecondsAfterFinished = *options.TTLSecondsAfterFinished
+	}
+	if options.RestartPolicy != "" {
+		restartPolicy = options.RestartPolicy
+	}
 	jobSpec := &batchv1.JobSpec{
 		BackoffLimit:            &backOffLimit,
 		TTLSecondsAfterFinished: &TTLSecondsAfterFinished,
@@ -42,10 +71,16 @@ func (self *PodManager) CreateJobSpec(jobNamePrefix string, containerName string
 						ImagePullPolicy: v1.PullAlways,
 					},
 				},
-				RestartPolicy: v1.RestartPolicyNever,
+				RestartPolicy:      restartPolicy,
+				ServiceAccountName: options.ServiceAccountName,
 			},
 		},
 	}
+	if options.ActiveDeadlineSeconds > 0 {
+		activeDeadlineSeconds := options.ActiveDeadlineSeconds
+		jobSpec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+	}
+
 	if envs != nil || len(envs) > 0 {
 		log.Printf("Adding %d environment variable to the container %s", len(envs), containerName)
 		jobSpec.Template.Spec.Containers[0].Env = envs
@@ -64,24 +99,314 @@ func (self *PodManager) CreateJobSpec(jobNamePrefix string, containerName string
 	return jobSpec
 }
 
-func (self *PodManager) CreateJob(namespace string, prefixName string, jobSpec batchv1.JobSpec) (*v1.Pod, error) {
+// CreateJob: creates the Job, labeled with the run it belongs to, and waits for its first pod.
+func (self *PodManager) CreateJob(namespace string, prefixName string, jobSpec batchv1.JobSpec, run RunInfo) (*v1.Pod, error) {
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: prefixName,
 			Namespace:    namespace,
+			Labels: map[string]string{
+				RunIDLabel:       labelValue(run.ID),
+				ChannelLabel:     labelValue(run.ChannelID),
+				ThreadTsLabel:    labelValue(run.ThreadTs),
+				EnvironmentLabel: labelValue(run.Environment),
+			},
+			Annotations: map[string]string{
+				RunIDLabel:          run.ID,
+				ChannelLabel:        run.ChannelID,
+				ThreadTsLabel:       run.ThreadTs,
+				EnvironmentLabel:    run.Environment,
+				CommandAnnotation:   run.Command,
+				RequesterAnnotation: run.RequesterID,
+			},
 		},
 		Spec: jobSpec,
 	}
 	job, err := self.client.BatchV1().Jobs(namespace).Create(job)
-	time.Sleep(150 * time.Millisecond)
 
 	if err != nil {
 		return nil, err
 	}
 	log.Printf("Job %s has been created successfuly", job.GetName())
-	pod, err := self.fetchPodNameFromJobName(namespace, job.GetName())
+	ctx, cancel := context.WithTimeout(context.Background(), podDiscoveryTimeout)
+	defer cancel()
+	pod, err := self.WaitForJobPod(ctx, namespace, job.GetName(), nil)
+	if err == context.DeadlineExceeded {
+		err = fmt.Errorf("No pod has been created for job %s on namespace %s after %s", job.GetName(), namespace, podDiscoveryTimeout)
+	}
 	if err != nil {
+		//Nobody would follow a pod created later on, the Job is deleted instead.
+		if deleteErr := self.DeleteJob(namespace, job.GetName()); deleteErr != nil {
+			log.Printf("Error when deleting job %s without pod : %s", job.GetName(), deleteErr.Error())
+		}
 		return nil, err
 	}
 	return pod, nil
 }
+
+// ListRunJobs: lists the Jobs of a namespace created for a run.
+func (self *PodManager) ListRunJobs(namespace string) ([]batchv1.Job, error) {
+	jobs, err := self.client.BatchV1().Jobs(namespace).List(metav1.ListOptions{LabelSelector: RunIDLabel})
+	if err != nil {
+		return nil, err
+	}
+	return jobs.Items, nil
+}
+
+// WatchRunJobs: calls onJob with every Job labeled with a run, then with every new one until ctx is cancelled.
+//@returns error: ctx error once cancelled, any error from kubernetes api.
+func (self *PodManager) WatchRunJobs(ctx context.Context, namespace string, onJob func(job *batchv1.Job)) error {
+	listOptions := metav1.ListOptions{LabelSelector: RunIDLabel}
+	for ctx.Err() == nil {
+		jobs, err := self.client.BatchV1().Jobs(namespace).List(listOptions)
+		if err != nil {
+			return err
+		}
+		for index := range jobs.Items {
+			onJob(&jobs.Items[index])
+		}
+
+		watchOptions := listOptions
+		watchOptions.ResourceVersion = jobs.ResourceVersion
+		watcher, err := self.client.BatchV1().Jobs(namespace).Watch(watchOptions)
+		if err != nil {
+			return err
+		}
+		watchAddedJobs(ctx, watcher, onJob)
+		watcher.Stop()
+	}
+	return ctx.Err()
+}
+
+//watchAddedJobs calls onJob with every Job added until ctx is cancelled or the watch is closed by the api server.
+func watchAddedJobs(ctx context.Context, watcher watch.Interface, onJob func(job *batchv1.Job)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-watcher.ResultChan():
+			if !open {
+				return
+			}
+			if job, ok := event.Object.(*batchv1.Job); ok && event.Type == watch.Added {
+				onJob(job)
+			}
+		}
+	}
+}
+
+// GetRunInfo: reads the run a Job belongs to from its labels and annotations.
+func GetRunInfo(job *batchv1.Job) RunInfo {
+	return RunInfo{
+		ID:          runValue(job, RunIDLabel),
+		ChannelID:   runValue(job, ChannelLabel),
+		ThreadTs:    runValue(job, ThreadTsLabel),
+		Environment: runValue(job, EnvironmentLabel),
+		Command:     job.Annotations[CommandAnnotation],
+		RequesterID: job.Annotations[RequesterAnnotation],
+	}
+}
+
+//runValue returns the run value kept in the annotation key, or in the label key for the Jobs which have no such annotation.
+func runValue(job *batchv1.Job, key string) string {
+	if value, ok := job.Annotations[key]; ok {
+		return value
+	}
+	return job.Labels[key]
+}
+
+// IsJobFinished: tells if the Job is Complete or Failed.
+func IsJobFinished(job *batchv1.Job) bool {
+	phase, _, _ := jobOutcome(job)
+	return phase != ""
+}
+
+// WaitForJobPod: waits for a pod of the Job to be created.
+// A Job retrying runs several pods, the ones already known are given in excluded so the next attempt is returned.
+//@args ctx: cancelling it (or its deadline) stops waiting for the pod.
+//@args excluded: names of the pods to ignore, may be nil.
+//@returns (*v1.Pod, error): the oldest pod of the Job not excluded, ctx error if none appears in time.
+func (self *PodManager) WaitForJobPod(ctx context.Context, namespace string, jobName string, excluded map[string]bool) (*v1.Pod, error) {
+	for {
+		pod, resourceVersion, err := self.oldestJobPod(namespace, jobName, excluded)
+		if pod != nil || err != nil {
+			return pod, err
+		}
+
+		watchOptions := metav1.ListOptions{LabelSelector: "job-name=" + jobName, ResourceVersion: resourceVersion}
+		watcher, err := self.client.CoreV1().Pods(namespace).Watch(watchOptions)
+		if err != nil {
+			return nil, err
+		}
+		pod, err = watchAddedPod(ctx, watcher, excluded)
+		watcher.Stop()
+		if pod != nil || err != nil {
+			return pod, err
+		}
+		//The API server closed the watch, pods are listed again so none created meanwhile is missed.
+		log.Printf("Watching pods of job %s has been interrupted, watching them again", jobName)
+	}
+}
+
+//oldestJobPod lists the pods of a Job, attempts being followed in their creation order.
+//@returns (*v1.Pod, string, error): the oldest pod not excluded (nil if there is none) and the resourceVersion of the list.
+func (self *PodManager) oldestJobPod(namespace string, jobName string, excluded map[string]bool) (*v1.Pod, string, error) {
+	pods, err := self.client.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: "job-name=" + jobName})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var oldest *v1.Pod
+	for index := range pods.Items {
+		pod := &pods.Items[index]
+		if !excluded[pod.Name] && (oldest == nil || pod.CreationTimestamp.Before(&oldest.CreationTimestamp)) {
+			oldest = pod
+		}
+	}
+	return oldest, pods.ResourceVersion, nil
+}
+
+//watchAddedPod waits for a pod not excluded to be added.
+//@returns (*v1.Pod, error): the added pod, nil if the watch has been closed, ctx error once cancelled.
+func watchAddedPod(ctx context.Context, watcher watch.Interface, excluded map[string]bool) (*v1.Pod, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, open := <-watcher.ResultChan():
+			if !open {
+				return nil, nil
+			}
+			if event.Type != watch.Added {
+				continue
+			}
+			if pod, ok := event.Object.(*v1.Pod); ok && !excluded[pod.Name] {
+				return pod, nil
+			}
+		}
+	}
+}
+
+// WaitForJob: follows every attempt (pod) of a Job until the Job is Complete or Failed.
+// Attempts are followed one after the other since Jobs are run without parallelism.
+//@args ctx: cancelling it stops following the Job.
+//@args podName: the pod of the first attempt.
+//@args handlers: called along the attempts.
+//@returns (*JobResult, error): the outcome from the Job status, error if the Job could not be followed until its end.
+// The result holds the followed attempts even on error.
+func (self *PodManager) WaitForJob(ctx context.Context, namespace string, jobName string, podName string, handlers JobHandlers) (*JobResult, error) {
+	result := &JobResult{}
+	excluded := map[string]bool{}
+	pod, err := self.GetPod(namespace, podName)
+	if err != nil {
+		return result, err
+	}
+
+	for {
+		excluded[pod.Name] = true
+		attempt := JobAttempt{Number: len(result.Attempts) + 1, PodName: pod.Name}
+		if handlers.OnAttemptStart != nil {
+			handlers.OnAttemptStart(attempt.Number, pod)
+		}
+
+		attempt.Logs, attempt.Phase, attempt.Err = self.GetPodLogs(ctx, namespace, pod.Name, handlers)
+		if exitCode, err := self.GetPodExitCode(namespace, pod.Name); err == nil {
+			attempt.ExitCode = exitCode
+		}
+		result.Attempts = append(result.Attempts, attempt)
+		if handlers.OnAttemptEnd != nil {
+			handlers.OnAttemptEnd(attempt)
+		}
+
+		var startErr *PodStartError
+		if ctx.Err() != nil || errors.As(attempt.Err, &startErr) {
+			return result, attempt.Err
+		}
+
+		//The wait strategy may end waiting before the pod ends (eg. on a log line), the Job is then considered Succeeded.
+		if attempt.Err == nil && attempt.Phase == string(v1.PodSucceeded) {
+			if current, err := self.GetPod(namespace, pod.Name); err == nil {
+				if _, ended := podEnded(current); !ended {
+					result.Phase, result.Reason = attempt.Phase, "WaitingFuncEnded"
+					return result, nil
+				}
+			}
+		}
+
+		nextPod, job, err := self.waitForJobNextStep(ctx, namespace, jobName, excluded)
+		if err != nil {
+			return result, err
+		}
+		if job != nil {
+			result.Phase, result.Reason, result.Message = jobOutcome(job)
+			return result, nil
+		}
+		pod = nextPod
+	}
+}
+
+//waitForJobNextStep waits for the Job to either end or start a new attempt.
+//@returns (*v1.Pod, *batchv1.Job, error): the pod of the new attempt or the ended Job.
+func (self *PodManager) waitForJobNextStep(ctx context.Context, namespace string, jobName string, excluded map[string]bool) (*v1.Pod, *batchv1.Job, error) {
+	watcher, err := self.client.BatchV1().Jobs(namespace).Watch(metav1.SingleObject(metav1.ObjectMeta{Namespace: namespace, Name: jobName}))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer watcher.Stop()
+
+	podCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type podResult struct {
+		pod *v1.Pod
+		err error
+	}
+	podFound := make(chan podResult, 1)
+	go func() {
+		pod, err := self.WaitForJobPod(podCtx, namespace, jobName, excluded)
+		podFound <- podResult{pod, err}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case found := <-podFound:
+			return found.pod, nil, found.err
+		case event, open := <-watcher.ResultChan():
+			if !open {
+				return nil, nil, errors.New("Watching job " + jobName + " has been interrupted")
+			}
+			job, ok := event.Object.(*batchv1.Job)
+			if !ok {
+				return nil, nil, errors.New("Unexpected type for *batchv1.Job whithin watcher event loop")
+			}
+			if event.Type == watch.Deleted {
+				return nil, nil, errors.New("Job " + jobName + " has been deleted")
+			}
+			if phase, _, _ := jobOutcome(job); phase != "" {
+				//Attempts not followed yet are followed before the outcome of the Job is reported.
+				if pod, _, err := self.oldestJobPod(namespace, jobName, excluded); err == nil && pod != nil {
+					return pod, nil, nil
+				}
+				return nil, job, nil
+			}
+		}
+	}
+}
+
+//jobOutcome reads the Complete or Failed condition of a Job.
+//@returns (string, string, string): phase (Succeeded, Failed or empty while running), reason and message of the condition.
+func jobOutcome(job *batchv1.Job) (string, string, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return string(v1.PodSucceeded), condition.Reason, condition.Message
+		case batchv1.JobFailed:
+			return string(v1.PodFailed), condition.Reason, condition.Message
+		}
+	}
+	return "", "", ""
+}
@@ -0,0 +1,198 @@
+/**
+ * File              : job_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testStartTime = time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+//newTestPod returns the index-th pod of job, later pods having higher indexes.
+func newTestPod(job string, name string, index int) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         "default",
+		ResourceVersion:   "1",
+		Labels:            map[string]string{"job-name": job},
+		CreationTimestamp: metav1.NewTime(testStartTime.Add(time.Duration(index) * time.Second)),
+	}}
+}
+
+//scriptWatches makes the watches of resource return the watchers built by watchers, one per watch.
+//Unscripted watches never receive any event.
+func scriptWatches(client *fake.Clientset, resource string, watchers ...func() watch.Interface) {
+	count := 0
+	client.PrependWatchReactor(resource, func(action k8stesting.Action) (bool, watch.Interface, error) {
+		count++
+		if count > len(watchers) {
+			return true, watch.NewFake(), nil
+		}
+		return true, watchers[count-1](), nil
+	})
+}
+
+//sentWatcher returns a watcher which has already received events, closed once they are read if closed is set.
+func sentWatcher(closed bool, events ...watch.Event) func() watch.Interface {
+	return func() watch.Interface {
+		watcher := watch.NewFakeWithChanSize(len(events), false)
+		for _, event := range events {
+			watcher.Action(event.Type, event.Object)
+		}
+		if closed {
+			watcher.Stop()
+		}
+		return watcher
+	}
+}
+
+func TestWaitForJobPod(t *testing.T) {
+	tests := []struct {
+		name     string
+		pods     []runtime.Object
+		excluded map[string]bool
+		watches  func(client *fake.Clientset) []func() watch.Interface
+		want     string
+		wantErr  error
+	}{
+		{
+			name: "oldest pod",
+			pods: []runtime.Object{newTestPod("job", "pod-3", 3), newTestPod("job", "pod-2", 2), newTestPod("job", "pod-1", 1), newTestPod("other-job", "other-pod", 0)},
+			want: "pod-1",
+		},
+		{
+			name:     "excluded pods",
+			pods:     []runtime.Object{newTestPod("job", "pod-1", 1), newTestPod("job", "pod-2", 2)},
+			excluded: map[string]bool{"pod-1": true},
+			want:     "pod-2",
+		},
+		{
+			name:     "pod added while watching",
+			pods:     []runtime.Object{newTestPod("job", "pod-1", 1)},
+			excluded: map[string]bool{"pod-1": true},
+			watches: func(client *fake.Clientset) []func() watch.Interface {
+				return []func() watch.Interface{sentWatcher(false,
+					watch.Event{Type: watch.Modified, Object: newTestPod("job", "pod-1", 1)},
+					watch.Event{Type: watch.Added, Object: newTestPod("job", "pod-2", 2)},
+				)}
+			},
+			want: "pod-2",
+		},
+		{
+			name: "watch closed early",
+			watches: func(client *fake.Clientset) []func() watch.Interface {
+				return []func() watch.Interface{func() watch.Interface {
+					//The pod is created while no watch is open, only listing the pods again finds it.
+					client.Tracker().Add(newTestPod("job", "pod-1", 1))
+					return sentWatcher(true)()
+				}}
+			},
+			want: "pod-1",
+		},
+		{
+			name:    "no pod",
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.pods...)
+			if test.watches != nil {
+				scriptWatches(client, "pods", test.watches(client)...)
+			}
+			manager := &PodManager{client: client}
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			pod, err := manager.WaitForJobPod(ctx, "default", "job", test.excluded)
+			if err != test.wantErr {
+				t.Fatalf("Expected error %v, got %v", test.wantErr, err)
+			}
+			if err == nil && pod.Name != test.want {
+				t.Errorf("Expected pod %s, got %s", test.want, pod.Name)
+			}
+		})
+	}
+}
+
+func TestWaitForJobNextStepFollowsEveryAttempt(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default", ResourceVersion: "2"},
+		Status:     batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Reason: "BackoffLimitExceeded"}}},
+	}
+	client := fake.NewSimpleClientset(newTestPod("job", "pod-3", 3), newTestPod("job", "pod-1", 1), newTestPod("job", "pod-2", 2))
+	//The Job has already failed when the first attempt ends, its retries are followed anyway.
+	failed := watch.Event{Type: watch.Modified, Object: job}
+	scriptWatches(client, "jobs", sentWatcher(false, failed), sentWatcher(false, failed), sentWatcher(false, failed))
+	manager := &PodManager{client: client}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	excluded := map[string]bool{"pod-1": true}
+	followed := []string{}
+	for {
+		pod, endedJob, err := manager.waitForJobNextStep(ctx, "default", "job", excluded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if endedJob != nil {
+			break
+		}
+		excluded[pod.Name] = true
+		followed = append(followed, pod.Name)
+	}
+
+	if want := []string{"pod-2", "pod-3"}; !reflect.DeepEqual(followed, want) {
+		t.Errorf("Expected attempts %v to be followed, got %v", want, followed)
+	}
+}
+
+func TestGetRunInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		job  *batchv1.Job
+		want RunInfo
+	}{
+		{
+			name: "raw values in annotations",
+			job: &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{RunIDLabel: "run1", ChannelLabel: "C01", ThreadTsLabel: "1.2", EnvironmentLabel: "pr-prod"},
+				Annotations: map[string]string{RunIDLabel: "run1", ChannelLabel: "C01", ThreadTsLabel: "1.2", EnvironmentLabel: "préprod", CommandAnnotation: "/migration préprod v1", RequesterAnnotation: "U01"},
+			}},
+			want: RunInfo{ID: "run1", ChannelID: "C01", ThreadTs: "1.2", Environment: "préprod", Command: "/migration préprod v1", RequesterID: "U01"},
+		},
+		{
+			name: "jobs labeled before annotations",
+			job: &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{RunIDLabel: "run1", ChannelLabel: "C01", ThreadTsLabel: "1.2", EnvironmentLabel: "prod"},
+				Annotations: map[string]string{CommandAnnotation: "/migration prod v1", RequesterAnnotation: "U01"},
+			}},
+			want: RunInfo{ID: "run1", ChannelID: "C01", ThreadTs: "1.2", Environment: "prod", Command: "/migration prod v1", RequesterID: "U01"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GetRunInfo(test.job); got != test.want {
+				t.Errorf("Expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
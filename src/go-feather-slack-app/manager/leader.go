@@ -0,0 +1,59 @@
+/**
+ * File              : leader.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+//LeaderElectionOptions describes the Lease replicas compete for.
+type LeaderElectionOptions struct {
+	LeaseName      string
+	LeaseNamespace string
+	Identity       string
+	LeaseDuration  time.Duration
+	RenewDeadline  time.Duration
+	RetryPeriod    time.Duration
+}
+
+// RunLeaderElection: competes for the Lease until ctx is cancelled, competing again every time the leadership is lost.
+//@args onStartedLeading: called once the Lease is acquired, its context is cancelled when the leadership is lost.
+//@args onStoppedLeading: called every time the election stops, whether this replica was leading or not.
+//@returns error if the election can't be set up.
+func (self *PodManager) RunLeaderElection(ctx context.Context, options LeaderElectionOptions, onStartedLeading func(ctx context.Context), onStoppedLeading func()) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: options.LeaseName, Namespace: options.LeaseNamespace},
+		Client:     self.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: options.Identity},
+	}
+
+	for ctx.Err() == nil {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   options.LeaseDuration,
+			RenewDeadline:   options.RenewDeadline,
+			RetryPeriod:     options.RetryPeriod,
+			ReleaseOnCancel: true,
+			Name:            options.LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: onStartedLeading,
+				OnStoppedLeading: onStoppedLeading,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		elector.Run(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/**
+ * File              : lock.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import (
+	"log"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//lockStaleAfter is how long a lock is kept for a run without Job, the Job is created right after the lock is acquired.
+const lockStaleAfter = time.Minute
+
+//LockHolder is the run holding a lock.
+type LockHolder struct {
+	RunID       string
+	RequesterID string
+	Command     string
+	Since       time.Time
+}
+
+//LockedError is returned when a lock is held by another run.
+type LockedError struct {
+	Holder LockHolder
+}
+
+func (self *LockedError) Error() string {
+	return "Locked by run " + self.Holder.RunID + " since " + self.Holder.Since.Format(time.RFC3339)
+}
+
+// AcquireLock: takes the lock name of namespace for a run, the lock is a Lease held by the run ID.
+// A lock whose run has had no unfinished Job for lockStaleAfter is taken over, its bot may have failed to release it.
+//@returns error: *LockedError if another run holds the lock, any error from kubernetes api.
+func (self *PodManager) AcquireLock(namespace string, name string, holder LockHolder) error {
+	leases := self.client.CoordinationV1().Leases(namespace)
+	lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	setLockHolder(lease, holder)
+	_, err := leases.Create(lease)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	current, err := leases.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	currentHolder := getLockHolder(current)
+	if currentHolder.RunID == holder.RunID {
+		return nil
+	}
+	held, err := self.isLockHeld(namespace, currentHolder)
+	if err != nil {
+		return err
+	}
+	if held {
+		return &LockedError{Holder: currentHolder}
+	}
+
+	log.Printf("Taking over lock %s from run %s which has no running job", name, currentHolder.RunID)
+	setLockHolder(current, holder)
+	if _, err := leases.Update(current); err != nil {
+		if apierrors.IsConflict(err) {
+			return &LockedError{Holder: currentHolder}
+		}
+		return err
+	}
+	return nil
+}
+
+// ReleaseLock: releases the lock name of namespace if it is held by the run.
+func (self *PodManager) ReleaseLock(namespace string, name string, runID string) error {
+	leases := self.client.CoordinationV1().Leases(namespace)
+	lease, err := leases.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if getLockHolder(lease).RunID != runID {
+		return nil
+	}
+	err = leases.Delete(name, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &lease.UID, ResourceVersion: &lease.ResourceVersion}})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+// GetLockHolder: returns the run holding the lock name of namespace without taking it.
+//@returns (*LockHolder, error): nil if the lock is free or could be taken over, any error from kubernetes api.
+func (self *PodManager) GetLockHolder(namespace string, name string) (*LockHolder, error) {
+	lease, err := self.client.CoordinationV1().Leases(namespace).Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	holder := getLockHolder(lease)
+	held, err := self.isLockHeld(namespace, holder)
+	if err != nil || !held {
+		return nil, err
+	}
+	return &holder, nil
+}
+
+//isLockHeld tells whether the holder still holds its lock, it may be taken over once its run has had no unfinished Job for lockStaleAfter.
+func (self *PodManager) isLockHeld(namespace string, holder LockHolder) (bool, error) {
+	if time.Since(holder.Since) < lockStaleAfter {
+		return true, nil
+	}
+	return self.HasUnfinishedJob(namespace, holder.RunID)
+}
+
+// HasUnfinishedJob: tells whether the run has a Job which is neither Complete nor Failed, Jobs being deleted are considered finished.
+func (self *PodManager) HasUnfinishedJob(namespace string, runID string) (bool, error) {
+	jobs, err := self.client.BatchV1().Jobs(namespace).List(metav1.ListOptions{LabelSelector: RunIDLabel + "=" + labelValue(runID)})
+	if err != nil {
+		return false, err
+	}
+	for index := range jobs.Items {
+		if jobs.Items[index].DeletionTimestamp == nil && !IsJobFinished(&jobs.Items[index]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func setLockHolder(lease *coordinationv1.Lease, holder LockHolder) {
+	since := metav1.NewMicroTime(holder.Since)
+	lease.Spec.HolderIdentity = &holder.RunID
+	lease.Spec.AcquireTime = &since
+	lease.Annotations = map[string]string{RequesterAnnotation: holder.RequesterID, CommandAnnotation: holder.Command}
+}
+
+func getLockHolder(lease *coordinationv1.Lease) LockHolder {
+	holder := LockHolder{RequesterID: lease.Annotations[RequesterAnnotation], Command: lease.Annotations[CommandAnnotation]}
+	if lease.Spec.HolderIdentity != nil {
+		holder.RunID = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.AcquireTime != nil {
+		holder.Since = lease.Spec.AcquireTime.Time
+	} else {
+		holder.Since = lease.CreationTimestamp.Time
+	}
+	return holder
+}
@@ -2,18 +2,17 @@
 * File              : main.go
 * Author            : Alexandre Saison <alexandre.saison@inarix.com>
 * Date              : 09.12.2020
-* Last Modified Date: 22.01.2021
+* Last Modified Date: 17.10.2026
 * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package podManager
 
 import (
-	"errors"
-	"fmt"
 	"log"
 	"os"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -43,16 +42,36 @@ func New(inCluster bool) *PodManager {
 	return &PodManager{client: clientset}
 }
 
+// CreateConfigRefSpec: references the ConfigMaps injected in a Job.
+// They are required, a missing ConfigMap keeps the pod in CreateContainerConfigError so the run is aborted.
 func (self *PodManager) CreateConfigRefSpec(configMapRefsNames []string) []v1.ConfigMapEnvSource {
 	configMapRefs := make([]v1.ConfigMapEnvSource, len(configMapRefsNames))
 	for index, configMapName := range configMapRefsNames {
-		isOptionnal := true
+		isOptionnal := false
 		tmpConfigMapRef := &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: configMapName}, Optional: &isOptionnal}
 		configMapRefs[index] = *tmpConfigMapRef
 	}
 	return configMapRefs
 }
 
+// GetConfigMapsData: returns the values of the ConfigMaps by key, missing ConfigMaps are skipped since their Job can't start anyway.
+//@returns (map[string]string, error): the values, error on any other error from kubernetes api.
+func (self *PodManager) GetConfigMapsData(namespace string, configMapNames []string) (map[string]string, error) {
+	data := map[string]string{}
+	for _, configMapName := range configMapNames {
+		configMap, err := self.client.CoreV1().ConfigMaps(namespace).Get(configMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for key, value := range configMap.Data {
+			data[key] = value
+		}
+	}
+	return data, nil
+}
+
 func (self *PodManager) CreateEnvsRefSpec(envMapRefsValues map[string]string) []v1.EnvVar {
 	envMapRefs := make([]v1.EnvVar, len(envMapRefsValues))
 	indexMap := 0
@@ -63,17 +82,3 @@ func (self *PodManager) CreateEnvsRefSpec(envMapRefsValues map[string]string) []
 	}
 	return envMapRefs
 }
-
-func (self *PodManager) fetchPodNameFromJobName(namespace string, jobName string) (*v1.Pod, error) {
-	labelSelector := "job-name=" + jobName
-	pods, err := self.client.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: labelSelector})
-	if err != nil {
-		return nil, err
-	}
-	if len(pods.Items) == 1 {
-		return &pods.Items[0], nil
-	}
-
-	errorMessage := fmt.Sprintf("No pod found with job-name=%s on namespace %s", jobName, namespace)
-	return nil, errors.New(errorMessage)
-}
@@ -0,0 +1,28 @@
+/**
+ * File              : main_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import "testing"
+
+func TestCreateConfigRefSpec(t *testing.T) {
+	manager := &PodManager{}
+	configMapRefs := manager.CreateConfigRefSpec([]string{"backend-db", "billing-db"})
+	if len(configMapRefs) != 2 {
+		t.Fatalf("Expected 2 ConfigMap refs, got %d", len(configMapRefs))
+	}
+	for index, name := range []string{"backend-db", "billing-db"} {
+		configMapRef := configMapRefs[index]
+		if configMapRef.Name != name {
+			t.Errorf("Expected ConfigMap %s, got %s", name, configMapRef.Name)
+		}
+		//A missing ConfigMap must keep the pod from starting so the run is aborted.
+		if configMapRef.Optional == nil || *configMapRef.Optional {
+			t.Errorf("Expected ConfigMap %s to be required", name)
+		}
+	}
+}
@@ -2,18 +2,25 @@
  * File              : pod.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 29.12.2020
- * Last Modified Date: 18.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package podManager
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"io/ioutil"
 	"log"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
 )
 
 func (self *PodManager) DeletePod(namespace string, podName string) error {
@@ -31,16 +38,36 @@ func (self *PodManager) GetPod(namespace string, podName string) (*v1.Pod, error
 	return self.client.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
 }
 
+// GetPodExitCode: returns the exit code of the pod first container.
+//@returns (*int32, error): the exit code, nil if the container is not terminated.
+func (self *PodManager) GetPodExitCode(namespace string, podName string) (*int32, error) {
+	pod, err := self.GetPod(namespace, podName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Terminated != nil {
+			exitCode := containerStatus.State.Terminated.ExitCode
+			return &exitCode, nil
+		}
+	}
+	return nil, nil
+}
+
 // GetPodLogs: use namespace and podName args to fetch logs of an ended pod.
 // Most of the time, it is used for Jobs since waits for pod to be completed.
+//@args ctx: cancelling it stops waiting for the pod.
 //@args namespace: Namespace of the pod to watch for logs.
 //@args podName: Name of the pod's logs to fetch on previously specified namespace.
+//@args handlers: WaitingFunc decides when waiting is over, OnUpdate is called with the pod while waiting,
+// OnLine with every log line as soon as the pod is running and OnRestart with the logs of the previous container
+// when the container restarts (attempt handlers are not used).
 //@returns (string, string, error):
-// string -> returns the logs of the ended pod.
+// string -> returns the logs of the ended pod last container (logs followed so far when waiting failed).
 // string -> returns last post status (Completed/Error/Oom ...)
 // error -> any error from kubernetes api.
-func (self *PodManager) GetPodLogs(namespace string, podName string) (string, string, error) {
-	podLogOpts := v1.PodLogOptions{}
+func (self *PodManager) GetPodLogs(ctx context.Context, namespace string, podName string, handlers JobHandlers) (string, string, error) {
 	log.Printf("Getting logs from %s in namespace %s", podName, namespace)
 	pod, err := self.GetPod(namespace, podName)
 
@@ -48,13 +75,78 @@ func (self *PodManager) GetPodLogs(namespace string, podName string) (string, st
 		return "", "", err
 	}
 
-	podPhase, err := self.WaitForPodReady(namespace, pod)
+	onLine, onRestart, onUpdate := handlers.OnLine, handlers.OnRestart, handlers.OnUpdate
+	waitingFunc := handlers.WaitingFunc
+	if waitingFunc == nil {
+		waitingFunc = DefaultHandlerWaitingFunc
+	}
+
+	//Logs are followed from the moment the container has started, they can't be read while it is waiting.
+	//Following is stopped when the wait strategy ends before the pod.
+	followCtx, stopFollowing := context.WithCancel(ctx)
+	defer stopFollowing()
+	var logs strings.Builder
+	var followed chan error
+	startFollowing := func(p *v1.Pod) {
+		if onLine == nil || followed != nil || !containerStarted(p) {
+			return
+		}
+		followed = make(chan error, 1)
+		go func() {
+			followed <- self.FollowPodLogs(followCtx, namespace, podName, func(line string) {
+				logs.WriteString(line + "\n")
+				onLine(line)
+			})
+		}()
+	}
+
+	startFollowing(pod)
+	restarts := podRestartCount(pod)
+	podPhase, err := self.WaitForPodReady(ctx, namespace, pod, waitingFunc, func(p *v1.Pod) {
+		//The logs of the restarted container are reported and a new one is followed.
+		if count := podRestartCount(p); count > restarts {
+			restarts = count
+			if followed != nil {
+				<-followed
+				followed = nil
+			}
+			if onRestart != nil {
+				onRestart(count, self.getPreviousLogs(namespace, podName))
+			}
+			logs.Reset()
+		}
+		startFollowing(p)
+		if onUpdate != nil {
+			onUpdate(p)
+		}
+	})
 	if err != nil {
 		log.Printf("Error while waiting for pod readiness : %s", err.Error())
-		return "", "", err
+		if followed != nil {
+			<-followed
+		}
+		return logs.String(), "", err
+	}
+
+	if onLine != nil {
+		if current, err := self.GetPod(namespace, podName); err == nil {
+			if _, ended := podEnded(current); !ended {
+				stopFollowing()
+			}
+		}
+		startFollowing(&v1.Pod{Status: v1.PodStatus{Phase: v1.PodPhase(podPhase)}})
+		//The wait strategy may end before the container has started, there are no logs to wait for then.
+		if followed == nil {
+			return logs.String(), podPhase, nil
+		}
+		err := <-followed
+		if err == nil {
+			return logs.String(), podPhase, nil
+		}
+		log.Printf("Error while following logs of pod %s, reading them again : %s", podName, err.Error())
 	}
 
-	req := self.client.CoreV1().Pods(namespace).GetLogs(podName, &podLogOpts)
+	req := self.client.CoreV1().Pods(namespace).GetLogs(podName, &v1.PodLogOptions{})
 	reader, err := req.Stream()
 
 	if err != nil {
@@ -72,13 +164,83 @@ func (self *PodManager) GetPodLogs(namespace string, podName string) (string, st
 	return string(body), podPhase, nil
 }
 
-func (self *PodManager) WaitForPodReady(namespace string, pod *v1.Pod) (string, error) {
-	watcher, err := self.client.CoreV1().Pods(namespace).Watch(metav1.SingleObject(metav1.ObjectMeta{Namespace: namespace, Name: pod.GetName()}))
+//getPreviousLogs reads the logs of the previous container of a restarted pod.
+func (self *PodManager) getPreviousLogs(namespace string, podName string) string {
+	reader, err := self.client.CoreV1().Pods(namespace).GetLogs(podName, &v1.PodLogOptions{Previous: true}).Stream()
+	if err != nil {
+		log.Printf("Error when fetching previous logs of pod %s : %s", podName, err.Error())
+		return ""
+	}
+	defer reader.Close()
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		log.Printf("Error when reading previous logs of pod %s : %s", podName, err.Error())
+	}
+	return string(body)
+}
+
+//containerStarted tells if the logs of the pod can be read, its container is running or has ended.
+func containerStarted(pod *v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return true
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil || status.State.Terminated != nil {
+			return true
+		}
+	}
+	return false
+}
+
+//podRestartCount sums the restarts of the containers of a pod.
+func podRestartCount(pod *v1.Pod) int32 {
+	count := int32(0)
+	for _, status := range pod.Status.ContainerStatuses {
+		count += status.RestartCount
+	}
+	return count
+}
+
+//FollowPodLogs streams the logs of a started pod line by line until its container ends or ctx is cancelled.
+//@args onLine: called with every log line, without its trailing new line.
+//@returns error: any error from kubernetes api or while reading the stream.
+func (self *PodManager) FollowPodLogs(ctx context.Context, namespace string, podName string, onLine LogLineFunc) error {
+	req := self.client.CoreV1().Pods(namespace).GetLogs(podName, &v1.PodLogOptions{Follow: true})
+	reader, err := req.Context(ctx).Stream()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		onLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// WaitForPodReady: waits for the pod with waitingFunc (DefaultHandlerWaitingFunc when nil).
+//@returns (string, error): the phase returned by waitingFunc.
+func (self *PodManager) WaitForPodReady(ctx context.Context, namespace string, pod *v1.Pod, waitingFunc HandlerWaitingFunc, onUpdate PodUpdateFunc) (string, error) {
+	if waitingFunc == nil {
+		waitingFunc = DefaultHandlerWaitingFunc
+	}
+
+	//The API server closes watches from time to time, the pod is then watched again from the last resourceVersion seen.
+	watcher, err := watchtools.NewRetryWatcher(pod.GetResourceVersion(), &cache.ListWatch{WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", pod.GetName()).String()
+		return self.client.CoreV1().Pods(namespace).Watch(options)
+	}})
 	if err != nil {
 		return "", err
 	}
 
-	podPhase, err := DefaultHandlerWaitingFunc(watcher, pod)
+	podPhase, err := waitingFunc(ctx, watcher, pod, onUpdate)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,63 @@
+/**
+ * File              : pod_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package podManager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForPodReadyWatchesAgainWhenClosed(t *testing.T) {
+	pod := podInPhase(v1.PodRunning, "")
+	succeeded := podInPhase(v1.PodSucceeded, "")
+	succeeded.ResourceVersion = "2"
+	client := fake.NewSimpleClientset(pod)
+	scriptWatches(client, "pods", sentWatcher(true), sentWatcher(false, watch.Event{Type: watch.Modified, Object: succeeded}))
+	manager := &PodManager{client: client}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	phase, err := manager.WaitForPodReady(ctx, "default", pod, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the pod to be watched again, got %s", err.Error())
+	}
+	if phase != string(v1.PodSucceeded) {
+		t.Errorf("Expected phase Succeeded, got %s", phase)
+	}
+}
+
+func TestGetPodLogsWaitingEndedBeforeContainerStarted(t *testing.T) {
+	pod := podInPhase(v1.PodPending, "")
+	manager := &PodManager{client: fake.NewSimpleClientset(pod)}
+	handlers := JobHandlers{
+		WaitingFunc: func(ctx context.Context, watcher watch.Interface, pod *v1.Pod, onUpdate PodUpdateFunc) (string, error) {
+			watcher.Stop()
+			return string(v1.PodRunning), nil
+		},
+		OnLine: func(line string) {},
+	}
+
+	ended := make(chan string, 1)
+	go func() {
+		_, phase, _ := manager.GetPodLogs(context.Background(), "default", pod.Name, handlers)
+		ended <- phase
+	}()
+	select {
+	case phase := <-ended:
+		if phase != string(v1.PodRunning) {
+			t.Errorf("Expected phase Running, got %s", phase)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected GetPodLogs to return without waiting for logs which are not followed")
+	}
+}
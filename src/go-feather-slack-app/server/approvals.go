@@ -0,0 +1,228 @@
+/**
+ * File              : approvals.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultApprovalTimeout = 30 * time.Minute
+	//approvalSweepPeriod is how often the leader expires the approvals whose replica did not expire them.
+	approvalSweepPeriod = 30 * time.Second
+	approveJobActionID  = "approve_job"
+	rejectJobActionID   = "reject_job"
+)
+
+//pendingApproval is a job launch waiting for the approval of another authorized user.
+type pendingApproval struct {
+	id        string
+	request   *CommandRequest
+	payload   *JobCreationPayload
+	messageTs string
+	expiresAt time.Time
+}
+
+//encode returns the approval as kept in the approvals store, shared by the replicas.
+func (self *pendingApproval) encode() (*JobHistory.Approval, error) {
+	request, err := json.Marshal(self.request)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(self.payload)
+	if err != nil {
+		return nil, err
+	}
+	return &JobHistory.Approval{ID: self.id, Request: request, Payload: payload, ChannelID: self.payload.AnswerChannel, MessageTs: self.messageTs, ExpiresAt: self.expiresAt}, nil
+}
+
+func decodeApproval(stored *JobHistory.Approval) (*pendingApproval, error) {
+	approval := &pendingApproval{id: stored.ID, request: &CommandRequest{}, payload: &JobCreationPayload{}, messageTs: stored.MessageTs, expiresAt: stored.ExpiresAt}
+	if err := json.Unmarshal(stored.Request, approval.request); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stored.Payload, approval.payload); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+//getApproval reads a pending approval from the approvals store.
+//@returns (*pendingApproval, error): JobHistory.ErrApprovalNotFound if it has already been handled.
+func (self *Server) getApproval(id string) (*pendingApproval, error) {
+	stored, err := self.approvals.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeApproval(stored)
+}
+
+//takeApproval removes a pending approval from the approvals store, a single replica takes it.
+//@returns error: JobHistory.ErrApprovalNotFound if it has already been handled.
+func (self *Server) takeApproval(id string) error {
+	_, err := self.approvals.Take(id)
+	return err
+}
+
+//requiresApproval tells whether the environment targeted by payload needs a second person to approve launches.
+func (self *Server) requiresApproval(payload *JobCreationPayload) bool {
+	environment, ok := self.config.ENVIRONMENTS[payload.Environment]
+	return ok && environment.RequireApproval
+}
+
+//requestApproval posts the Approve/Reject message instead of launching the job right away.
+//@args request: the command request of the requester
+//@args payload: the job to launch once approved
+func (self *Server) requestApproval(request *CommandRequest, payload *JobCreationPayload) {
+	timeout := self.config.ENVIRONMENTS[payload.Environment].ApprovalTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultApprovalTimeout
+	}
+
+	approval := &pendingApproval{id: newIdentifier(), request: request, payload: payload, expiresAt: time.Now().Add(timeout)}
+	text := fmt.Sprintf("<@%s> wants to run `%s` on *%s* with image `%s`.\nAnother authorized user must approve it before <!date^%d^{time}|%s>.",
+		request.UserID, request.commandLine(), payload.Environment, payload.DockerImage, approval.expiresAt.Unix(), approval.expiresAt.Format(time.RFC3339))
+
+	approveButton := slack.NewButtonBlockElement(approveJobActionID, approval.id, slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false))
+	rejectButton := slack.NewButtonBlockElement(rejectJobActionID, approval.id, slack.NewTextBlockObject(slack.PlainTextType, "Reject", false, false))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+		slack.NewActionBlock("approval-"+approval.id, approveButton.WithStyle(slack.StylePrimary), rejectButton.WithStyle(slack.StyleDanger)),
+	}
+
+	_, messageTs, err := self.slackClient.PostMessage(payload.AnswerChannel, slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...))
+	if err != nil {
+		log.Printf("Error when posting approval request : %s", err.Error())
+		self.sendSlackResponse("I could not ask for an approval : "+err.Error(), request.ResponseURL)
+		return
+	}
+
+	approval.messageTs = messageTs
+	stored, err := approval.encode()
+	if err == nil {
+		err = self.approvals.Add(stored)
+	}
+	if err != nil {
+		log.Printf("Error when saving approval request %s : %s", approval.id, err.Error())
+		self.closeApproval(approval, fmt.Sprintf(":x: `%s` requested by <@%s> could not be saved for approval", request.commandLine(), request.UserID))
+		self.sendSlackResponse("I could not ask for an approval : "+err.Error(), request.ResponseURL)
+		return
+	}
+	time.AfterFunc(timeout, func() { self.expireApproval(approval.id) })
+
+	self.audit("approval_requested", request.UserID, fmt.Sprintf("request %s `%s` on %s", approval.id, request.commandLine(), payload.Environment))
+	self.sendSlackResponse("Your request needs to be approved by someone else, I've asked for it in <#"+payload.AnswerChannel+">", request.ResponseURL)
+}
+
+func (self *Server) handleApprovalAction(callback *slack.InteractionCallback, action *slack.BlockAction) {
+	approved := action.ActionID == approveJobActionID
+	approval, err := self.getApproval(action.Value)
+	if errors.Is(err, JobHistory.ErrApprovalNotFound) {
+		self.sendSlackResponse(err.Error(), callback.ResponseURL)
+		return
+	} else if err != nil {
+		log.Printf("Error when reading approval request %s : %s", action.Value, err.Error())
+		self.sendSlackResponse("I could not read this request : "+err.Error(), callback.ResponseURL)
+		return
+	}
+	//The replica which asked for the approval may be gone, its expiration is enforced by whichever replica handles it.
+	if time.Now().After(approval.expiresAt) {
+		self.expireApproval(approval.id)
+		self.sendSlackResponse(JobHistory.ErrApprovalNotFound.Error(), callback.ResponseURL)
+		return
+	}
+
+	isRequester := callback.User.ID == approval.request.UserID
+	if approved && isRequester {
+		self.sendSlackResponse("You can't approve your own request, someone else has to", callback.ResponseURL)
+		return
+	}
+
+	if !isRequester {
+		approverRequest := &CommandRequest{Command: approval.request.Command, Subcommand: approval.request.Subcommand, UserID: callback.User.ID, UserName: callback.User.Name, ChannelID: callback.Channel.ID}
+		if err := self.authorize(approverRequest, approval.payload.Environment); err != nil {
+			self.audit("approval_denied", callback.User.ID, fmt.Sprintf("request %s : %s", approval.id, err.Error()))
+			self.sendSlackResponse(err.Error(), callback.ResponseURL)
+			return
+		}
+	}
+
+	if err := self.takeApproval(approval.id); err != nil {
+		if !errors.Is(err, JobHistory.ErrApprovalNotFound) {
+			log.Printf("Error when taking approval request %s : %s", approval.id, err.Error())
+		}
+		self.sendSlackResponse(err.Error(), callback.ResponseURL)
+		return
+	}
+
+	if !approved {
+		self.audit("job_rejected", callback.User.ID, fmt.Sprintf("request %s of <@%s> `%s` on %s", approval.id, approval.request.UserID, approval.request.commandLine(), approval.payload.Environment))
+		self.closeApproval(approval, fmt.Sprintf(":no_entry: `%s` requested by <@%s> has been rejected by <@%s>", approval.request.commandLine(), approval.request.UserID, callback.User.ID))
+		self.sendSlackResponse("Your request `"+approval.request.commandLine()+"` has been rejected by <@"+callback.User.ID+">", approval.request.ResponseURL)
+		return
+	}
+
+	self.audit("job_approved", callback.User.ID, fmt.Sprintf("request %s of <@%s> `%s` on %s", approval.id, approval.request.UserID, approval.request.commandLine(), approval.payload.Environment))
+	self.closeApproval(approval, fmt.Sprintf(":white_check_mark: `%s` requested by <@%s> has been approved by <@%s>", approval.request.commandLine(), approval.request.UserID, callback.User.ID))
+	self.SubmitJobCreation(approval.request, approval.payload)
+}
+
+func (self *Server) expireApproval(id string) {
+	approval, err := self.getApproval(id)
+	if err == nil {
+		err = self.takeApproval(id)
+	}
+	if err != nil {
+		if !errors.Is(err, JobHistory.ErrApprovalNotFound) {
+			log.Printf("Error when expiring approval request %s : %s", id, err.Error())
+		}
+		return
+	}
+
+	self.audit("approval_expired", approval.request.UserID, fmt.Sprintf("request %s `%s` on %s", approval.id, approval.request.commandLine(), approval.payload.Environment))
+	self.closeApproval(approval, fmt.Sprintf(":hourglass: `%s` requested by <@%s> has expired without approval", approval.request.commandLine(), approval.request.UserID))
+	self.sendSlackResponse("Your request `"+approval.request.commandLine()+"` has expired without approval", approval.request.ResponseURL)
+}
+
+//closeApproval replaces the approval message by text, removing its buttons.
+func (self *Server) closeApproval(approval *pendingApproval, text string) {
+	block := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+	if _, _, _, err := self.slackClient.UpdateMessage(approval.payload.AnswerChannel, approval.messageTs, slack.MsgOptionText(text, false), slack.MsgOptionBlocks(block)); err != nil {
+		log.Printf("Error when updating approval message %s : %s", approval.id, err.Error())
+	}
+}
+
+//sweepApprovals expires the overdue approvals until ctx is cancelled,
+//the replica which asked for an approval expires it on time unless it has been stopped meanwhile.
+func (self *Server) sweepApprovals(ctx context.Context) {
+	for {
+		approvals, err := self.approvals.List()
+		if err != nil {
+			log.Printf("Error when listing pending approvals : %s", err.Error())
+		}
+		for _, approval := range approvals {
+			if time.Now().After(approval.ExpiresAt) {
+				self.expireApproval(approval.ID)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(approvalSweepPeriod):
+		}
+	}
+}
@@ -0,0 +1,30 @@
+/**
+ * File              : audit.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"fmt"
+	"log"
+)
+
+//audit records a security relevant action in the server logs and in the audit channel when configured.
+//@args action: what happened (eg. command_denied)
+//@args userID: the Slack user who did the action
+//@args details: human readable details about the action
+func (self *Server) audit(action string, userID string, details string) {
+	log.Printf("[AUDIT] action=%s user=%s %s", action, userID, details)
+
+	if self.config.AUTHORIZATION.AuditChannel == "" {
+		return
+	}
+
+	message := fmt.Sprintf(":closed_lock_with_key: `%s` by <@%s> : %s", action, userID, details)
+	if _, err := self.sendSlackMessageWithClient(self.config.AUTHORIZATION.AuditChannel, message, ""); err != nil {
+		log.Printf("Error when posting audit message : %s", err.Error())
+	}
+}
@@ -0,0 +1,129 @@
+/**
+ * File              : authorization.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const userGroupCacheDuration = 5 * time.Minute
+
+//AuthorizationRule grants Users and members of UserGroups the right to run Commands on Environments from Channels.
+//Commands entries are either a command (/migration) matching all its subcommands, or a command and a subcommand (/migration cancel).
+//Empty Commands or Environments lists match everything, empty Channels allow any channel.
+type AuthorizationRule struct {
+	Commands     []string `json:"commands"`
+	Environments []string `json:"environments"`
+	Users        []string `json:"users"`
+	UserGroups   []string `json:"userGroups"`
+	Channels     []string `json:"channels"`
+}
+
+//AuthorizationConfig lists the authorization rules, a request not matched by any rule scope is allowed.
+type AuthorizationConfig struct {
+	AuditChannel string               `json:"auditChannel"`
+	Rules        []*AuthorizationRule `json:"rules"`
+}
+
+type userGroupMembers struct {
+	members   []string
+	fetchedAt time.Time
+}
+
+//userGroupCache avoids calling usergroups.users.list on every command.
+type userGroupCache struct {
+	mutex  sync.Mutex
+	groups map[string]*userGroupMembers
+}
+
+//appliesTo tells whether the rule scope covers command on environment.
+func (self *AuthorizationRule) appliesTo(request *CommandRequest, environment string) bool {
+	return (len(self.Commands) == 0 || contains(self.Commands, request.Command) || contains(self.Commands, strings.TrimSpace(request.Command+" "+request.Subcommand))) &&
+		(len(self.Environments) == 0 || contains(self.Environments, environment))
+}
+
+//authorize checks the authorization rules before running a command.
+//@args request: the command request holding the user and channel
+//@args environment: the environment targeted by the command, empty if none
+//@returns error explaining why the request is denied, nil if it is allowed.
+func (self *Server) authorize(request *CommandRequest, environment string) error {
+	applicable := 0
+	for _, rule := range self.config.AUTHORIZATION.Rules {
+		if !rule.appliesTo(request, environment) {
+			continue
+		}
+		applicable++
+
+		if len(rule.Channels) > 0 && !contains(rule.Channels, request.ChannelID) {
+			continue
+		}
+		if len(rule.Users) == 0 && len(rule.UserGroups) == 0 {
+			return nil
+		}
+		if contains(rule.Users, request.UserID) || self.isInUserGroups(request.UserID, rule.UserGroups) {
+			return nil
+		}
+	}
+
+	if applicable == 0 {
+		return nil
+	}
+
+	command := strings.TrimSpace(request.Command + " " + request.Subcommand)
+	if environment != "" {
+		return errors.New("You are not allowed to run `" + command + "` on " + environment + " from this channel")
+	}
+	return errors.New("You are not allowed to run `" + command + "` from this channel")
+}
+
+func (self *Server) isInUserGroups(userID string, userGroups []string) bool {
+	for _, userGroup := range userGroups {
+		members, err := self.userGroups.members(self, userGroup)
+		if err != nil {
+			log.Printf("Error when fetching members of user group %s : %s", userGroup, err.Error())
+			continue
+		}
+		if contains(members, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *userGroupCache) members(server *Server, userGroup string) ([]string, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.groups == nil {
+		self.groups = make(map[string]*userGroupMembers)
+	}
+
+	if cached, ok := self.groups[userGroup]; ok && time.Since(cached.fetchedAt) < userGroupCacheDuration {
+		return cached.members, nil
+	}
+
+	members, err := server.slackClient.GetUserGroupMembers(userGroup)
+	if err != nil {
+		return nil, err
+	}
+	self.groups[userGroup] = &userGroupMembers{members: members, fetchedAt: time.Now()}
+	return members, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, current := range values {
+		if current == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+/**
+ * File              : commands.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	jobKindMigration = "migration"
+	jobKindSeed      = "seed"
+)
+
+//CommandRequest holds everything Slack sent along with a slash command.
+type CommandRequest struct {
+	Command     string
+	Subcommand  string
+	Arguments   []string
+	Text        string
+	UserID      string
+	UserName    string
+	ChannelID   string
+	ResponseURL string
+}
+
+//commandLine rebuilds the command as typed by the user.
+func (self *CommandRequest) commandLine() string {
+	return strings.TrimSpace(self.Command + " " + self.Text)
+}
+
+//CommandHandler is implemented by every slash command (or subcommand) the bot answers to.
+type CommandHandler interface {
+	//Help returns the usage of the command, shown with the help subcommand or on wrong usage.
+	Help() string
+	//Validate checks the arguments of the command before anything is launched.
+	Validate(arguments []string) error
+	//BuildJob returns the description of the Job to launch for the request.
+	BuildJob(request *CommandRequest) (*JobCreationPayload, error)
+}
+
+//CommandRunner is implemented by handlers answering by themselves instead of launching a Job.
+//Run is called in its own goroutine and must answer through the request ResponseURL.
+type CommandRunner interface {
+	Run(request *CommandRequest)
+}
+
+//EnvironmentTargeter is implemented by CommandRunner handlers acting on an environment,
+//their requests are authorized against it instead of against every environment.
+type EnvironmentTargeter interface {
+	//TargetEnvironment returns the environment the request acts on, empty if none.
+	TargetEnvironment(request *CommandRequest) (string, error)
+}
+
+//CommandRegistry maps slash commands and their subcommands to their handlers.
+type CommandRegistry struct {
+	handlers    map[string]CommandHandler
+	subcommands map[string]map[string]CommandHandler
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		handlers:    make(map[string]CommandHandler),
+		subcommands: make(map[string]map[string]CommandHandler),
+	}
+}
+
+//Register the handler used when command is called without any known subcommand.
+func (self *CommandRegistry) Register(command string, handler CommandHandler) {
+	self.handlers[command] = handler
+}
+
+//RegisterSubcommand registers the handler used when command first argument is subcommand (eg. /migration help).
+func (self *CommandRegistry) RegisterSubcommand(command string, subcommand string, handler CommandHandler) {
+	if _, ok := self.subcommands[command]; !ok {
+		self.subcommands[command] = make(map[string]CommandHandler)
+	}
+	self.subcommands[command][subcommand] = handler
+}
+
+//Lookup finds the handler of a slash command.
+//@args command: the slash command (eg. /migration)
+//@args arguments: the slash command text split into fields
+//@returns (CommandHandler, *CommandRequest, error): the handler and the request with the subcommand (if any) stripped from its arguments.
+func (self *CommandRegistry) Lookup(command string, arguments []string) (CommandHandler, *CommandRequest, error) {
+	request := &CommandRequest{Command: command, Arguments: arguments, Text: strings.Join(arguments, " ")}
+
+	if len(arguments) > 0 {
+		if handler, ok := self.subcommands[command][arguments[0]]; ok {
+			request.Subcommand = arguments[0]
+			request.Arguments = arguments[1:]
+			return handler, request, nil
+		}
+	}
+
+	handler, ok := self.handlers[command]
+	if !ok {
+		return nil, nil, errors.New("Current slack command is not implemented yet !")
+	}
+	return handler, request, nil
+}
+
+//Help concatenates the usage of command and of all its subcommands.
+func (self *CommandRegistry) Help(command string) string {
+	var usages []string
+	if handler, ok := self.handlers[command]; ok {
+		usages = append(usages, handler.Help())
+	}
+
+	subcommands := make([]string, 0, len(self.subcommands[command]))
+	for subcommand := range self.subcommands[command] {
+		subcommands = append(subcommands, subcommand)
+	}
+	sort.Strings(subcommands)
+	for _, subcommand := range subcommands {
+		usages = append(usages, self.subcommands[command][subcommand].Help())
+	}
+	return strings.Join(usages, "\n")
+}
+
+func (self *Server) registerCommands() {
+	self.commands = NewCommandRegistry()
+
+	migrationHandler := &sequelizeJobHandler{server: self, command: self.config.MIGRATION_COMMAND, kind: jobKindMigration}
+	seedHandler := &sequelizeJobHandler{server: self, command: self.config.SEED_COMMAND, kind: jobKindSeed}
+
+	for _, handler := range []*sequelizeJobHandler{migrationHandler, seedHandler} {
+		self.commands.Register(handler.command, handler)
+		self.commands.RegisterSubcommand(handler.command, "help", &helpHandler{server: self, command: handler.command})
+		self.commands.RegisterSubcommand(handler.command, "cancel", &cancelHandler{server: self, command: handler.command})
+		self.commands.RegisterSubcommand(handler.command, "retry", &retryHandler{server: self, command: handler.command})
+		self.commands.RegisterSubcommand(handler.command, "history", &historyHandler{server: self, command: handler.command, kind: handler.kind})
+	}
+}
+
+//sequelizeJobHandler launches a Job running a Sequelize migration or seed,
+//the name of the migration/seed is given to the container through the service env variable of this kind.
+type sequelizeJobHandler struct {
+	server  *Server
+	command string
+	kind    string
+}
+
+func (self *sequelizeJobHandler) Help() string {
+	return "`" + self.command + " [environment] [service] <version> <" + self.kind + " name> [configMaps...]` launches the " + self.kind + " using the given image version"
+}
+
+func (self *sequelizeJobHandler) Validate(arguments []string) error {
+	_, arguments, err := self.server.config.findTarget(arguments)
+	if err != nil {
+		return err
+	}
+
+	if len(arguments) < 2 {
+		return errors.New("You must at least specify a version and a " + self.kind + " name !")
+	}
+
+	version := arguments[0]
+	if !self.server.isValidVersion(version) {
+		return errors.New("You must specify a good version (eg. v.1.0.0) : " + version)
+	}
+	return nil
+}
+
+func (self *sequelizeJobHandler) BuildJob(request *CommandRequest) (*JobCreationPayload, error) {
+	target, arguments, err := self.server.config.findTarget(request.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if !target.Service.isChannelAllowed(request.ChannelID) {
+		return nil, errors.New("Service " + target.ServiceName + " can't be used from this channel")
+	}
+
+	dockerTag := arguments[0]
+	commandConfig := self.server.config.commandConfig(self.command)
+	configMapsNames := append([]string{}, target.Environment.ConfigMaps...)
+	configMapsNames = append(configMapsNames, target.Service.ConfigMaps...)
+	payload := &JobCreationPayload{
+		Kind:            self.kind,
+		Environment:     target.EnvironmentName,
+		Service:         target.ServiceName,
+		Version:         dockerTag,
+		Name:            arguments[1],
+		DockerImage:     target.Image() + ":" + dockerTag,
+		Namespace:       target.Environment.Namespace,
+		ServiceAccount:  target.Environment.ServiceAccount,
+		AnswerChannel:   target.Environment.AnswerChannel,
+		JobName:         newJobName(),
+		EnvVariablesMap: map[string]string{target.Service.envName(self.kind): arguments[1]},
+		ConfigMapsNames: append(configMapsNames, arguments[2:]...),
+		DeadlineSeconds: int64(self.server.config.jobDeadline(self.command, target.Environment).Seconds()),
+		BackoffLimit:    commandConfig.BackoffLimit,
+		TTLSeconds:      commandConfig.TTLSecondsAfterFinished,
+		RestartPolicy:   commandConfig.RestartPolicy,
+	}
+
+	return payload, nil
+}
+
+//helpHandler answers with the usage of a command and its subcommands.
+type helpHandler struct {
+	server  *Server
+	command string
+}
+
+func (self *helpHandler) Help() string {
+	return "`" + self.command + " help` shows this message"
+}
+
+func (self *helpHandler) Validate(arguments []string) error {
+	return nil
+}
+
+func (self *helpHandler) BuildJob(request *CommandRequest) (*JobCreationPayload, error) {
+	return nil, errors.New("`" + self.command + " help` does not launch any job")
+}
+
+func (self *helpHandler) Run(request *CommandRequest) {
+	self.server.sendSlackResponse(self.server.commands.Help(self.command), request.ResponseURL)
+}
@@ -0,0 +1,365 @@
+/**
+ * File              : config.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultEnvironmentName = "default"
+	defaultServiceName     = "default"
+)
+
+//EnvironmentConfig describes a target environment in which jobs are launched.
+type EnvironmentConfig struct {
+	Namespace       string   `json:"namespace"`
+	ImageRepository string   `json:"imageRepository"`
+	ConfigMaps      []string `json:"configMaps"`
+	ServiceAccount  string   `json:"serviceAccount"`
+	AnswerChannel   string   `json:"answerChannel"`
+	//RequireApproval makes every launch wait for the approval of another authorized user.
+	RequireApproval bool            `json:"requireApproval"`
+	ApprovalTimeout metav1.Duration `json:"approvalTimeout"`
+	//Deadline is the longest time a job may run in this environment before being killed.
+	Deadline metav1.Duration `json:"deadline"`
+	//AllowConcurrentJobs disables the lock preventing two jobs from running at the same time in this environment.
+	AllowConcurrentJobs bool `json:"allowConcurrentJobs"`
+}
+
+//ServiceConfig describes a Feathers/Sequelize backend whose migrations and seeds can be launched.
+//When Image is empty, the ImageRepository of the targeted environment is used.
+type ServiceConfig struct {
+	Image            string   `json:"image"`
+	MigrationEnvName string   `json:"migrationEnvName"`
+	SeedEnvName      string   `json:"seedEnvName"`
+	ConfigMaps       []string `json:"configMaps"`
+	AllowedChannels  []string `json:"allowedChannels"`
+}
+
+//CommandConfig holds the settings of the jobs launched by a slash command.
+type CommandConfig struct {
+	//Deadline is the longest time a job may run before being killed.
+	Deadline metav1.Duration `json:"deadline"`
+	//BackoffLimit is the count of retries of a failed job (0 by default).
+	BackoffLimit *int32 `json:"backoffLimit"`
+	//TTLSecondsAfterFinished is how long an ended job is kept before being cleaned up (120 by default).
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished"`
+	//RestartPolicy is either Never (default, a retry runs a new pod) or OnFailure (a retry restarts the container).
+	RestartPolicy string `json:"restartPolicy"`
+	//Wait chooses how the end of the jobs is waited for.
+	Wait WaitConfig `json:"wait"`
+}
+
+//WaitConfig chooses the wait strategy of the jobs of a command.
+type WaitConfig struct {
+	//Strategy is either phase (default) or logLine.
+	Strategy string `json:"strategy"`
+	//Phases end waiting with the phase strategy, on top of Succeeded and Failed (eg. Running).
+	Phases []string `json:"phases"`
+	//LogLine is the regular expression of the log line ending waiting with the logLine strategy, the run is then Succeeded.
+	LogLine string `json:"logLine"`
+	//Progress pushes the phase changes of the pod to the run status message (true by default), they are recorded either way.
+	Progress *bool `json:"progress"`
+
+	logLinePattern *regexp.Regexp
+}
+
+const (
+	waitStrategyPhase   = "phase"
+	waitStrategyLogLine = "logLine"
+)
+
+//Check the wait settings of a command and compile its log line pattern.
+//@returns error if the settings are invalid.
+func (self *WaitConfig) resolve() error {
+	switch self.Strategy {
+	case "", waitStrategyPhase:
+		for _, phase := range self.Phases {
+			switch v1.PodPhase(phase) {
+			case v1.PodPending, v1.PodRunning, v1.PodSucceeded, v1.PodFailed:
+			default:
+				return errors.New("Unknown pod phase " + phase)
+			}
+		}
+	case waitStrategyLogLine:
+		if self.LogLine == "" {
+			return errors.New("The logLine wait strategy needs a logLine pattern")
+		}
+		pattern, err := regexp.Compile(self.LogLine)
+		if err != nil {
+			return errors.New("Invalid logLine pattern " + self.LogLine + " : " + err.Error())
+		}
+		self.logLinePattern = pattern
+	default:
+		return errors.New("Unknown wait strategy " + self.Strategy + " (phase or logLine)")
+	}
+	return nil
+}
+
+//waitingFunc builds the wait strategy.
+func (self *WaitConfig) waitingFunc(manager *PodManager.PodManager) PodManager.HandlerWaitingFunc {
+	waitingFunc := PodManager.HandlerWaitingFunc(PodManager.DefaultHandlerWaitingFunc)
+	if self.logLinePattern != nil {
+		waitingFunc = manager.WaitForLogLine(self.logLinePattern)
+	} else if len(self.Phases) > 0 {
+		phases := []v1.PodPhase{}
+		for _, phase := range self.Phases {
+			phases = append(phases, v1.PodPhase(phase))
+		}
+		waitingFunc = PodManager.WaitForPhase(phases...)
+	}
+	return waitingFunc
+}
+
+//FileConfig is the content of the yaml file given through APP_CONFIG_FILE.
+type FileConfig struct {
+	DefaultEnvironment string                        `json:"defaultEnvironment"`
+	Environments       map[string]*EnvironmentConfig `json:"environments"`
+	DefaultService     string                        `json:"defaultService"`
+	Commands           map[string]*CommandConfig     `json:"commands"`
+	Services           map[string]*ServiceConfig     `json:"services"`
+	Authorization      AuthorizationConfig           `json:"authorization"`
+	History            JobHistory.Config             `json:"history"`
+	Logs               LogsConfig                    `json:"logs"`
+	HighAvailability   HighAvailabilityConfig        `json:"highAvailability"`
+	ShutdownTimeout    metav1.Duration               `json:"shutdownTimeout"`
+
+	implicitEnvironment bool
+}
+
+//JobTarget is the environment and service a command is launched against.
+type JobTarget struct {
+	EnvironmentName string
+	Environment     *EnvironmentConfig
+	ServiceName     string
+	Service         *ServiceConfig
+}
+
+//Read and parse the yaml configuration file.
+//@args path: path of the configuration file
+//@returns (*FileConfig, error): the parsed configuration, error if the file can't be read or parsed.
+func loadConfigFile(path string) (*FileConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileConfig := &FileConfig{}
+	if err := yaml.UnmarshalStrict(content, fileConfig); err != nil {
+		return nil, fmt.Errorf("Invalid configuration file %s : %s", path, err.Error())
+	}
+	return fileConfig, nil
+}
+
+//Fill every environment missing settings with the ones given by environment variables.
+//When no environment is declared, a single "default" one is created from them.
+//@args dockerImage: value of APP_DOCKER_IMAGE
+//@args answerChannel: value of SLACK_ANSWER_CHANNEL_ID
+//@returns error if an environment can't be used to launch jobs.
+func (self *FileConfig) resolveEnvironments(dockerImage string, answerChannel string) error {
+	if len(self.Environments) == 0 {
+		self.Environments = map[string]*EnvironmentConfig{defaultEnvironmentName: {}}
+		self.DefaultEnvironment = defaultEnvironmentName
+		self.implicitEnvironment = true
+	}
+
+	if self.DefaultEnvironment == "" && len(self.Environments) == 1 {
+		for name := range self.Environments {
+			self.DefaultEnvironment = name
+		}
+	}
+
+	if _, ok := self.Environments[self.DefaultEnvironment]; self.DefaultEnvironment != "" && !ok {
+		return errors.New("Default environment " + self.DefaultEnvironment + " is not declared")
+	}
+
+	for name, environment := range self.Environments {
+		if environment == nil {
+			environment = &EnvironmentConfig{}
+			self.Environments[name] = environment
+		}
+		if environment.Namespace == "" {
+			environment.Namespace = "default"
+		}
+		if environment.ImageRepository == "" {
+			environment.ImageRepository = dockerImage
+		}
+		if environment.AnswerChannel == "" {
+			environment.AnswerChannel = answerChannel
+		}
+		if environment.ImageRepository == "" || environment.AnswerChannel == "" {
+			return errors.New("Environment " + name + " has no image repository or answer channel (neither APP_DOCKER_IMAGE nor SLACK_ANSWER_CHANNEL_ID are set)")
+		}
+	}
+	return nil
+}
+
+//Fill every service missing settings with the ones given by environment variables.
+//When no service is declared, a single "default" one is created from them.
+//@args migrationEnvName: value of APP_SEQUELIZE_MIGRATION_ENV_NAME
+//@args seedEnvName: value of APP_SEQUELIZE_SEED_ENV_NAME
+//@returns error if a service can't be used to launch jobs.
+func (self *FileConfig) resolveServices(migrationEnvName string, seedEnvName string) error {
+	//Implicit environment and service are both named default, they are only used as defaults so they can't be mixed up.
+	implicitService := len(self.Services) == 0
+	if implicitService {
+		self.Services = map[string]*ServiceConfig{defaultServiceName: {}}
+		self.DefaultService = defaultServiceName
+	}
+
+	if self.DefaultService == "" && len(self.Services) == 1 {
+		for name := range self.Services {
+			self.DefaultService = name
+		}
+	}
+
+	if _, ok := self.Services[self.DefaultService]; self.DefaultService != "" && !ok {
+		return errors.New("Default service " + self.DefaultService + " is not declared")
+	}
+
+	for name, service := range self.Services {
+		if service == nil {
+			service = &ServiceConfig{}
+			self.Services[name] = service
+		}
+		if _, ok := self.Environments[name]; ok && !implicitService && !self.implicitEnvironment {
+			return errors.New("Service " + name + " has the same name than an environment")
+		}
+		if service.MigrationEnvName == "" {
+			service.MigrationEnvName = migrationEnvName
+		}
+		if service.SeedEnvName == "" {
+			service.SeedEnvName = seedEnvName
+		}
+		if service.MigrationEnvName == "" || service.SeedEnvName == "" {
+			return errors.New("Service " + name + " has no migration or seed env name (neither APP_SEQUELIZE_MIGRATION_ENV_NAME nor APP_SEQUELIZE_SEED_ENV_NAME are set)")
+		}
+	}
+	return nil
+}
+
+//Check the settings of every command.
+//@returns error if a command has invalid settings.
+func (self *FileConfig) resolveCommands() error {
+	for name, command := range self.Commands {
+		if command == nil {
+			self.Commands[name] = &CommandConfig{}
+			continue
+		}
+		if command.RestartPolicy != "" && command.RestartPolicy != string(v1.RestartPolicyNever) && command.RestartPolicy != string(v1.RestartPolicyOnFailure) {
+			return errors.New("Command " + name + " has an invalid restartPolicy " + command.RestartPolicy + " (Never or OnFailure)")
+		}
+		if command.BackoffLimit != nil && *command.BackoffLimit < 0 {
+			return errors.New("Command " + name + " has a negative backoffLimit")
+		}
+		if err := command.Wait.resolve(); err != nil {
+			return errors.New("Command " + name + " has invalid wait settings : " + err.Error())
+		}
+	}
+	return nil
+}
+
+//commandConfig returns the settings of a command, empty ones if it has none.
+func (self *ServerConfig) commandConfig(command string) *CommandConfig {
+	if commandConfig, ok := self.COMMANDS[command]; ok && commandConfig != nil {
+		return commandConfig
+	}
+	return &CommandConfig{}
+}
+
+//Find the environment and service targeted by a command.
+//Both are optional leading arguments in any order, defaults are used when missing.
+//@args arguments: the command arguments
+//@returns (*JobTarget, []string, error): the target and the remaining arguments.
+func (self *ServerConfig) findTarget(arguments []string) (*JobTarget, []string, error) {
+	target := &JobTarget{}
+	for len(arguments) > 0 {
+		if environment, ok := self.ENVIRONMENTS[arguments[0]]; ok && target.Environment == nil {
+			target.EnvironmentName, target.Environment = arguments[0], environment
+		} else if service, ok := self.SERVICES[arguments[0]]; ok && target.Service == nil {
+			target.ServiceName, target.Service = arguments[0], service
+		} else {
+			break
+		}
+		arguments = arguments[1:]
+	}
+
+	if target.Environment == nil {
+		if self.DEFAULT_ENVIRONMENT == "" {
+			return nil, arguments, errors.New("You must specify an environment among " + strings.Join(self.environmentNames(), ", "))
+		}
+		target.EnvironmentName, target.Environment = self.DEFAULT_ENVIRONMENT, self.ENVIRONMENTS[self.DEFAULT_ENVIRONMENT]
+	}
+
+	if target.Service == nil {
+		if self.DEFAULT_SERVICE == "" {
+			return nil, arguments, errors.New("You must specify a service among " + strings.Join(self.serviceNames(), ", "))
+		}
+		target.ServiceName, target.Service = self.DEFAULT_SERVICE, self.SERVICES[self.DEFAULT_SERVICE]
+	}
+	return target, arguments, nil
+}
+
+//Image returns the image repository of the target, the service one prevails over the environment one.
+func (self *JobTarget) Image() string {
+	if self.Service.Image != "" {
+		return self.Service.Image
+	}
+	return self.Environment.ImageRepository
+}
+
+//isChannelAllowed tells whether the service can be used from channelID.
+func (self *ServiceConfig) isChannelAllowed(channelID string) bool {
+	if len(self.AllowedChannels) == 0 {
+		return true
+	}
+	for _, allowedChannel := range self.AllowedChannels {
+		if allowedChannel == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+//envName returns the name of the environment variable holding the migration or seed name.
+func (self *ServiceConfig) envName(kind string) string {
+	if kind == jobKindSeed {
+		return self.SeedEnvName
+	}
+	return self.MigrationEnvName
+}
+
+func (self *ServerConfig) environmentNames() []string {
+	names := make([]string, 0, len(self.ENVIRONMENTS))
+	for name := range self.ENVIRONMENTS {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (self *ServerConfig) serviceNames() []string {
+	names := make([]string, 0, len(self.SERVICES))
+	for name := range self.SERVICES {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,188 @@
+/**
+ * File              : config_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+//setenv sets the environment variables for the duration of the test, empty values unset them.
+func setenv(t *testing.T, variables map[string]string) {
+	for name, value := range variables {
+		previous, existed := os.LookupEnv(name)
+		if value == "" {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, value)
+		}
+		name := name
+		t.Cleanup(func() {
+			if existed {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+func TestInitConfigWithoutConfigFile(t *testing.T) {
+	setenv(t, map[string]string{
+		"SLACK_API_TOKEN":                  "xoxb-token",
+		"SLACK_SIGNING_SECRET":             "secret",
+		"SLACK_ANSWER_CHANNEL_ID":          "C0ANSWER",
+		"APP_CONFIG_FILE":                  "",
+		"APP_DOCKER_IMAGE":                 "registry/app",
+		"APP_MIGRATION_COMMAND":            "",
+		"APP_SEED_COMMAND":                 "",
+		"APP_SEQUELIZE_MIGRATION_ENV_NAME": "MIGRATION_NAME",
+		"APP_SEQUELIZE_SEED_ENV_NAME":      "SEED_NAME",
+	})
+
+	config := initConfig()
+	if config.DEFAULT_ENVIRONMENT != defaultEnvironmentName || config.DEFAULT_SERVICE != defaultServiceName {
+		t.Fatalf("Expected default environment and service, got %q and %q", config.DEFAULT_ENVIRONMENT, config.DEFAULT_SERVICE)
+	}
+
+	environment := config.ENVIRONMENTS[defaultEnvironmentName]
+	if environment.Namespace != "default" || environment.ImageRepository != "registry/app" || environment.AnswerChannel != "C0ANSWER" {
+		t.Errorf("Unexpected default environment %+v", environment)
+	}
+	service := config.SERVICES[defaultServiceName]
+	if service.MigrationEnvName != "MIGRATION_NAME" || service.SeedEnvName != "SEED_NAME" {
+		t.Errorf("Unexpected default service %+v", service)
+	}
+	if config.MIGRATION_COMMAND != "/migration" || config.SEED_COMMAND != "/seed" {
+		t.Errorf("Unexpected commands %q and %q", config.MIGRATION_COMMAND, config.SEED_COMMAND)
+	}
+}
+
+func TestResolveServices(t *testing.T) {
+	tests := []struct {
+		name         string
+		environments map[string]*EnvironmentConfig
+		services     map[string]*ServiceConfig
+		wantDefault  string
+		wantErr      bool
+	}{
+		{name: "implicit environment and service", wantDefault: defaultServiceName},
+		{name: "declared environment named default", environments: map[string]*EnvironmentConfig{"default": {}}, wantDefault: defaultServiceName},
+		{name: "implicit environment and declared service named default", services: map[string]*ServiceConfig{"default": {}}, wantDefault: "default"},
+		{name: "single declared service is the default", environments: map[string]*EnvironmentConfig{"prod": {}}, services: map[string]*ServiceConfig{"billing": nil}, wantDefault: "billing"},
+		{name: "several declared services have no default", environments: map[string]*EnvironmentConfig{"prod": {}}, services: map[string]*ServiceConfig{"billing": {}, "users": {}}},
+		{name: "declared service named as a declared environment", environments: map[string]*EnvironmentConfig{"prod": {}}, services: map[string]*ServiceConfig{"prod": {}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileConfig := &FileConfig{Environments: test.environments, Services: test.services}
+			if err := fileConfig.resolveEnvironments("registry/app", "C0ANSWER"); err != nil {
+				t.Fatalf("Unexpected environments error : %s", err.Error())
+			}
+
+			err := fileConfig.resolveServices("MIGRATION_NAME", "SEED_NAME")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expected error %v, got %v", test.wantErr, err)
+			}
+			if err == nil && fileConfig.DefaultService != test.wantDefault {
+				t.Errorf("Expected default service %q, got %q", test.wantDefault, fileConfig.DefaultService)
+			}
+			for name, service := range fileConfig.Services {
+				if err == nil && (service == nil || service.MigrationEnvName != "MIGRATION_NAME") {
+					t.Errorf("Service %s has not been filled : %+v", name, service)
+				}
+			}
+		})
+	}
+}
+
+func TestFindTarget(t *testing.T) {
+	staging, prod := &EnvironmentConfig{Namespace: "staging"}, &EnvironmentConfig{Namespace: "prod"}
+	billing, users := &ServiceConfig{}, &ServiceConfig{}
+	config := &ServerConfig{
+		ENVIRONMENTS:        map[string]*EnvironmentConfig{"staging": staging, "prod": prod},
+		DEFAULT_ENVIRONMENT: "staging",
+		SERVICES:            map[string]*ServiceConfig{"billing": billing, "users": users},
+		DEFAULT_SERVICE:     "billing",
+	}
+	noDefaults := &ServerConfig{ENVIRONMENTS: config.ENVIRONMENTS, SERVICES: config.SERVICES}
+
+	tests := []struct {
+		name            string
+		config          *ServerConfig
+		arguments       []string
+		wantEnvironment string
+		wantService     string
+		wantArguments   []string
+		wantErr         bool
+	}{
+		{name: "defaults", config: config, arguments: []string{"v1.2.3", "add-users"}, wantEnvironment: "staging", wantService: "billing", wantArguments: []string{"v1.2.3", "add-users"}},
+		{name: "environment", config: config, arguments: []string{"prod", "v1.2.3"}, wantEnvironment: "prod", wantService: "billing", wantArguments: []string{"v1.2.3"}},
+		{name: "service", config: config, arguments: []string{"users", "v1.2.3"}, wantEnvironment: "staging", wantService: "users", wantArguments: []string{"v1.2.3"}},
+		{name: "environment and service", config: config, arguments: []string{"prod", "users", "v1.2.3"}, wantEnvironment: "prod", wantService: "users", wantArguments: []string{"v1.2.3"}},
+		{name: "service and environment", config: config, arguments: []string{"users", "prod", "v1.2.3"}, wantEnvironment: "prod", wantService: "users", wantArguments: []string{"v1.2.3"}},
+		{name: "second environment is an argument", config: config, arguments: []string{"prod", "staging"}, wantEnvironment: "prod", wantService: "billing", wantArguments: []string{"staging"}},
+		{name: "no arguments", config: config, arguments: []string{}, wantEnvironment: "staging", wantService: "billing", wantArguments: []string{}},
+		{name: "missing environment without default", config: noDefaults, arguments: []string{"users", "v1.2.3"}, wantErr: true},
+		{name: "missing service without default", config: noDefaults, arguments: []string{"prod", "v1.2.3"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, arguments, err := test.config.findTarget(test.arguments)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expected error %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if target.EnvironmentName != test.wantEnvironment || target.Environment != test.config.ENVIRONMENTS[test.wantEnvironment] {
+				t.Errorf("Expected environment %q, got %q", test.wantEnvironment, target.EnvironmentName)
+			}
+			if target.ServiceName != test.wantService || target.Service != test.config.SERVICES[test.wantService] {
+				t.Errorf("Expected service %q, got %q", test.wantService, target.ServiceName)
+			}
+			if !reflect.DeepEqual(arguments, test.wantArguments) {
+				t.Errorf("Expected arguments %v, got %v", test.wantArguments, arguments)
+			}
+		})
+	}
+}
+
+func TestWaitConfigResolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      WaitConfig
+		wantErr     bool
+		wantPattern bool
+	}{
+		{name: "default strategy", config: WaitConfig{}},
+		{name: "phase strategy", config: WaitConfig{Strategy: "phase", Phases: []string{"Running"}}},
+		{name: "every pod phase", config: WaitConfig{Phases: []string{"Pending", "Running", "Succeeded", "Failed"}}},
+		{name: "unknown phase", config: WaitConfig{Phases: []string{"Completed"}}, wantErr: true},
+		{name: "log line strategy", config: WaitConfig{Strategy: "logLine", LogLine: `^No migrations were executed`}, wantPattern: true},
+		{name: "log line strategy without pattern", config: WaitConfig{Strategy: "logLine"}, wantErr: true},
+		{name: "invalid log line pattern", config: WaitConfig{Strategy: "logLine", LogLine: `(unclosed`}, wantErr: true},
+		{name: "log line ignored by the phase strategy", config: WaitConfig{LogLine: `done`}},
+		{name: "unknown strategy", config: WaitConfig{Strategy: "exitCode"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.resolve()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expected error %v, got %v", test.wantErr, err)
+			}
+			if (test.config.logLinePattern != nil) != test.wantPattern {
+				t.Errorf("Expected log line pattern %v, got %v", test.wantPattern, test.config.logLinePattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+/**
+ * File              : deadlines.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+)
+
+//deadlineGracePeriod is left to kubernetes to kill a Job exceeding its deadline before the bot stops watching it.
+const deadlineGracePeriod = time.Minute
+
+//jobDeadline is the shortest of the deadlines set on the command and on the environment.
+//@returns time.Duration: 0 when no deadline is set.
+func (self *ServerConfig) jobDeadline(command string, environment *EnvironmentConfig) time.Duration {
+	deadline := environment.Deadline.Duration
+	if commandDeadline := self.commandConfig(command).Deadline.Duration; commandDeadline > 0 && (deadline <= 0 || commandDeadline < deadline) {
+		deadline = commandDeadline
+	}
+	if deadline < 0 {
+		return 0
+	}
+	return deadline
+}
+
+//withDeadline bounds the watching of a run to its deadline from its start, plus deadlineGracePeriod.
+func withDeadline(ctx context.Context, record *JobHistory.Record) (context.Context, context.CancelFunc) {
+	if record.DeadlineSeconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, record.StartedAt.Add(time.Duration(record.DeadlineSeconds)*time.Second+deadlineGracePeriod))
+}
+
+//deadlineExceeded tells if a run has been stopped for exceeding its deadline.
+//Either kubernetes killed the Job (activeDeadlineSeconds) or the watcher bound expired first, the Job is then deleted.
+func (self *Server) deadlineExceeded(ctx context.Context, record *JobHistory.Record) bool {
+	if record.DeadlineSeconds <= 0 {
+		return false
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		if err := self.manager.DeleteJob(record.Namespace, record.JobName); err != nil {
+			log.Printf("Error when deleting job %s which exceeded its deadline : %s", record.JobName, err.Error())
+		}
+		return true
+	}
+
+	reason, err := self.manager.GetJobFailureReason(record.Namespace, record.JobName)
+	if err != nil {
+		log.Printf("Error when fetching failure reason of job %s : %s", record.JobName, err.Error())
+		return false
+	}
+	return reason == "DeadlineExceeded"
+}
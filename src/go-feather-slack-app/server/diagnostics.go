@@ -0,0 +1,135 @@
+/**
+ * File              : diagnostics.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
+	"github.com/slack-go/slack"
+)
+
+const (
+	diagnosticsMaxEvents      = 10
+	diagnosticsMaxMessageSize = 300
+	//diagnosticsMaxSectionSize keeps each section under the 3000 characters Slack accepts in a section text.
+	diagnosticsMaxSectionSize = 2900
+)
+
+//diagnoseFailure gathers why the pod of a run failed and keeps its summary in the record.
+//@returns *PodManager.PodDiagnostics: nil if the pod can't be inspected anymore.
+func (self *Server) diagnoseFailure(record *JobHistory.Record) *PodManager.PodDiagnostics {
+	if record.PodName == "" {
+		return nil
+	}
+
+	diagnostics, err := self.manager.GetPodDiagnostics(record.Namespace, record.PodName)
+	if err != nil {
+		log.Printf("Error when gathering failure diagnostics of run %s : %s", record.ID, err.Error())
+		return nil
+	}
+	record.FailureReason = diagnostics.Summary()
+	return diagnostics
+}
+
+//postFailureDiagnostics posts a "why it failed" section in the run thread, messages are redacted as the logs are.
+func (self *Server) postFailureDiagnostics(record *JobHistory.Record, diagnostics *PodManager.PodDiagnostics, messageRedactor *redactor) {
+	if diagnostics == nil {
+		return
+	}
+
+	_, _, err := self.slackClient.PostMessage(record.ChannelID, slack.MsgOptionTS(record.ThreadTs), slack.MsgOptionText("Why it failed : "+record.FailureReason, false), slack.MsgOptionBlocks(diagnosticsBlocks(diagnostics, messageRedactor)...))
+	if err != nil {
+		log.Printf("Error when posting failure diagnostics of run %s : %s", record.ID, err.Error())
+	}
+}
+
+func diagnosticsBlocks(diagnostics *PodManager.PodDiagnostics, messageRedactor *redactor) []slack.Block {
+	title := ":mag: *Why it failed*"
+	if summary := diagnostics.Summary(); summary != "" {
+		title += " : " + summary
+	}
+	pod := "Pod `" + diagnostics.PodName + "` is *" + diagnostics.Phase + "*"
+	if diagnostics.Reason != "" {
+		pod += " (" + diagnostics.Reason + ")"
+	}
+	if diagnostics.Message != "" {
+		pod += "\n> " + truncateDiagnostic(messageRedactor.redact(diagnostics.Message))
+	}
+
+	blocks := []slack.Block{diagnosticsSection([]string{title, pod})}
+
+	if len(diagnostics.Containers) > 0 {
+		lines := []string{"*Containers*"}
+		for _, container := range diagnostics.Containers {
+			line := "• `" + container.Name + "` " + container.State
+			if container.Reason != "" {
+				line += " : *" + container.Reason + "*"
+			}
+			if container.ExitCode != nil {
+				line += ", exit code " + strconv.Itoa(int(*container.ExitCode))
+			}
+			if container.OOMKilled {
+				line += ", killed for exceeding its memory limit"
+			}
+			if container.RestartCount > 0 {
+				line += ", restarted " + strconv.Itoa(int(container.RestartCount)) + " times"
+			}
+			if container.Message != "" {
+				line += "\n> " + truncateDiagnostic(messageRedactor.redact(container.Message))
+			}
+			lines = append(lines, line)
+		}
+		blocks = append(blocks, diagnosticsSection(lines))
+	}
+
+	events := diagnostics.Events
+	if len(events) > diagnosticsMaxEvents {
+		events = events[len(events)-diagnosticsMaxEvents:]
+	}
+	if len(events) > 0 {
+		lines := []string{"*Events*"}
+		for _, event := range events {
+			line := "• *" + event.Reason + "* on " + event.Object
+			if event.Count > 1 {
+				line += " (x" + strconv.Itoa(int(event.Count)) + ")"
+			}
+			lines = append(lines, line+" : "+truncateDiagnostic(messageRedactor.redact(event.Message)))
+		}
+		blocks = append(blocks, diagnosticsSection(lines))
+	}
+	return blocks
+}
+
+//diagnosticsSection joins lines in a section, the lines which don't fit in diagnosticsMaxSectionSize are counted instead.
+func diagnosticsSection(lines []string) slack.Block {
+	text := ""
+	for index, line := range lines {
+		if index > 0 {
+			line = "\n" + line
+		}
+		if utf8.RuneCountInString(text)+utf8.RuneCountInString(line) > diagnosticsMaxSectionSize {
+			text += "\n…and " + strconv.Itoa(len(lines)-index) + " more"
+			break
+		}
+		text += line
+	}
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
+
+func truncateDiagnostic(message string) string {
+	message = strings.ReplaceAll(strings.TrimSpace(message), "\n", " ")
+	if runes := []rune(message); len(runes) > diagnosticsMaxMessageSize {
+		return string(runes[:diagnosticsMaxMessageSize]) + "…"
+	}
+	return message
+}
@@ -0,0 +1,83 @@
+/**
+ * File              : diagnostics_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
+	"github.com/slack-go/slack"
+)
+
+func TestTruncateDiagnostic(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "short", message: " exit status 1\n", want: "exit status 1"},
+		{name: "new lines", message: "line 1\nline 2", want: "line 1 line 2"},
+		{name: "at max size", message: strings.Repeat("é", diagnosticsMaxMessageSize), want: strings.Repeat("é", diagnosticsMaxMessageSize)},
+		{name: "over max size", message: strings.Repeat("é", diagnosticsMaxMessageSize+1), want: strings.Repeat("é", diagnosticsMaxMessageSize) + "…"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := truncateDiagnostic(test.message)
+			if got != test.want {
+				t.Errorf("Expected %q, got %q", test.want, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Expected a valid UTF-8 string, got %q", got)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsBlocks(t *testing.T) {
+	exitCode := int32(1)
+	diagnostics := &PodManager.PodDiagnostics{PodName: "migration-pod", Phase: "Failed", Message: "connecting with s3cr3t-password"}
+	for index := 0; index < 50; index++ {
+		diagnostics.Containers = append(diagnostics.Containers, PodManager.ContainerDiagnostic{
+			Name:     "migration",
+			State:    "Terminated",
+			Reason:   "Error",
+			ExitCode: &exitCode,
+			Message:  "password=hunter2 " + strings.Repeat("x", diagnosticsMaxMessageSize),
+		})
+	}
+	messageRedactor := &redactor{patterns: mustCompileRedactionPatterns(t), literals: []string{"s3cr3t-password"}}
+
+	blocks := diagnosticsBlocks(diagnostics, messageRedactor)
+	if len(blocks) != 2 {
+		t.Fatalf("Expected a pod and a containers section, got %d blocks", len(blocks))
+	}
+	for _, block := range blocks {
+		text := block.(*slack.SectionBlock).Text.Text
+		if size := utf8.RuneCountInString(text); size > diagnosticsMaxSectionSize {
+			t.Errorf("Expected at most %d characters in a section, got %d", diagnosticsMaxSectionSize, size)
+		}
+		if strings.Contains(text, "s3cr3t-password") || strings.Contains(text, "hunter2") {
+			t.Errorf("Expected messages to be redacted, got %q", text)
+		}
+	}
+	if text := blocks[1].(*slack.SectionBlock).Text.Text; !strings.HasSuffix(text, " more") {
+		t.Errorf("Expected the containers which don't fit to be counted, got %q", text[len(text)-50:])
+	}
+}
+
+func mustCompileRedactionPatterns(t *testing.T) []*regexp.Regexp {
+	patterns, err := compileRedactionPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patterns
+}
@@ -0,0 +1,363 @@
+/**
+ * File              : history.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	"github.com/slack-go/slack"
+)
+
+const (
+	logExcerptLines     = 20
+	logExcerptMaxBytes  = 2000
+	historyPageSize     = 10
+	historyPageActionID = "history_page"
+)
+
+//newRunRecord creates the history record of a run about to be launched.
+func (self *Server) newRunRecord(request *CommandRequest, payload *JobCreationPayload) *JobHistory.Record {
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error when marshalling job payload : %s", err.Error())
+	}
+
+	return &JobHistory.Record{
+		ID:              newIdentifier(),
+		RequesterID:     request.UserID,
+		RequesterName:   request.UserName,
+		Command:         request.commandLine(),
+		Kind:            payload.Kind,
+		Environment:     payload.Environment,
+		Service:         payload.Service,
+		Version:         payload.Version,
+		Image:           payload.DockerImage,
+		MigrationName:   payload.Name,
+		Namespace:       payload.Namespace,
+		Phase:           JobHistory.PhaseCreating,
+		StartedAt:       time.Now(),
+		DeadlineSeconds: payload.DeadlineSeconds,
+		ChannelID:       payload.AnswerChannel,
+		RetryOf:         payload.RetryOf,
+		Payload:         rawPayload,
+	}
+}
+
+//saveRecord persists the record, history failures never stop a run so they are only logged.
+func (self *Server) saveRecord(record *JobHistory.Record) {
+	if err := self.history.Save(record); err != nil {
+		log.Printf("Error when saving run %s in history : %s", record.ID, err.Error())
+	}
+}
+
+//saveRunRecord saves the record of a run followed by this replica.
+//The run may have been cancelled meanwhile from another replica, its cancellation is kept rather than overwritten.
+func (self *Server) saveRunRecord(record *JobHistory.Record) {
+	if stored, err := self.history.Get(record.ID); err == nil && stored.CancelledBy != "" && record.CancelledBy == "" {
+		merged := *record
+		merged.CancelledBy = stored.CancelledBy
+		if stored.FinishedAt != nil {
+			merged.Phase = stored.Phase
+			merged.FinishedAt = stored.FinishedAt
+		}
+		record = &merged
+	}
+	self.saveRecord(record)
+}
+
+//finishRecord marks the run as ended with phase, keeps the end of its logs and unlocks its environment if its Job has ended.
+func (self *Server) finishRecord(record *JobHistory.Record, phase string, logs string) {
+	finishedAt := time.Now()
+	record.Phase = phase
+	record.FinishedAt = &finishedAt
+	record.LogExcerpt = logExcerpt(logs)
+	self.saveRecord(record)
+	self.releaseLock(record)
+}
+
+//notifyRequester posts message in the run thread, mentioning its requester.
+//Slack allows only five uses of a response_url, the run thread is used once the Job is created.
+func (self *Server) notifyRequester(record *JobHistory.Record, message string) {
+	if _, err := self.sendSlackMessageWithClient(record.ChannelID, "<@"+record.RequesterID+"> "+message, record.ThreadTs); err != nil {
+		log.Printf("Error when notifying the requester of run %s : %s", record.ID, err.Error())
+	}
+}
+
+//linkRetriedRun points the thread of the retried run to the thread of its retry.
+func (self *Server) linkRetriedRun(record *JobHistory.Record) {
+	original, err := self.history.Get(record.RetryOf)
+	if err != nil {
+		log.Printf("Error when fetching retried run %s : %s", record.RetryOf, err.Error())
+		return
+	}
+
+	message := ":repeat: Retried as run `" + record.ID + "`"
+	if permalink, err := self.slackClient.GetPermalink(&slack.PermalinkParameters{Channel: record.ChannelID, Ts: record.ThreadTs}); err == nil {
+		message += " : " + permalink
+	}
+	self.sendSlackMessageWithClient(original.ChannelID, message, original.ThreadTs)
+}
+
+//commandName returns the slash command which launched the run (eg. /migration).
+func commandName(record *JobHistory.Record) string {
+	if fields := strings.Fields(record.Command); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+//logExcerpt keeps the last lines of logs, at most logExcerptMaxBytes long.
+func logExcerpt(logs string) string {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > logExcerptLines {
+		lines = lines[len(lines)-logExcerptLines:]
+	}
+
+	excerpt := strings.Join(lines, "\n")
+	if len(excerpt) > logExcerptMaxBytes {
+		excerpt = excerpt[len(excerpt)-logExcerptMaxBytes:]
+	}
+	return excerpt
+}
+
+//historyHandler lists the previous runs of a kind of job (eg. /migration history status:failed).
+type historyHandler struct {
+	server  *Server
+	command string
+	kind    string
+}
+
+//historyQuery is what is needed to render a page of history, it is kept in pagination buttons value.
+type historyQuery struct {
+	Command   string   `json:"command"`
+	Kind      string   `json:"kind"`
+	Arguments []string `json:"arguments"`
+	Page      int      `json:"page"`
+}
+
+func (self *historyHandler) Help() string {
+	return "`" + self.command + " history [env:<environment>] [user:<@user>] [status:<phase>] [since:YYYY-MM-DD] [until:YYYY-MM-DD] [page:<n>]` lists the previous " + self.kind + "s"
+}
+
+func (self *historyHandler) Validate(arguments []string) error {
+	_, _, err := parseHistoryArguments(arguments)
+	return err
+}
+
+func (self *historyHandler) BuildJob(request *CommandRequest) (*JobCreationPayload, error) {
+	return nil, errors.New("`" + self.command + " history` does not launch any job")
+}
+
+func (self *historyHandler) TargetEnvironment(request *CommandRequest) (string, error) {
+	filter, _, err := parseHistoryArguments(request.Arguments)
+	if err != nil {
+		return "", err
+	}
+	return filter.Environment, nil
+}
+
+func (self *historyHandler) Run(request *CommandRequest) {
+	query := &historyQuery{Command: self.command, Kind: self.kind, Arguments: request.Arguments}
+	self.server.sendHistoryPage(request, query, request.ResponseURL, false)
+}
+
+//handleHistoryPageAction renders the page asked through the Previous/Next buttons in place of the current one.
+//The button value is sent back by the client, the user who clicked is authorized again on the query environment.
+func (self *Server) handleHistoryPageAction(callback *slack.InteractionCallback, action *slack.BlockAction) {
+	query := &historyQuery{}
+	if err := json.Unmarshal([]byte(action.Value), query); err != nil {
+		log.Printf("Error when reading history page action : %s", err.Error())
+		return
+	}
+
+	request := &CommandRequest{Command: query.Command, Subcommand: "history", Arguments: query.Arguments, Text: strings.Join(append([]string{"history"}, query.Arguments...), " "), UserID: callback.User.ID, UserName: callback.User.Name, ChannelID: callback.Channel.ID, ResponseURL: callback.ResponseURL}
+	filter, _, err := parseHistoryArguments(query.Arguments)
+	if err != nil {
+		self.sendSlackResponse(err.Error(), callback.ResponseURL)
+		return
+	}
+	if err := self.authorize(request, filter.Environment); err != nil {
+		self.audit("command_denied", request.UserID, "`"+request.commandLine()+"` : "+err.Error())
+		self.sendSlackResponse(err.Error(), callback.ResponseURL)
+		return
+	}
+	self.sendHistoryPage(request, query, callback.ResponseURL, true)
+}
+
+//sendHistoryPage renders a page of the runs matching query, only the environments request is allowed on are listed.
+func (self *Server) sendHistoryPage(request *CommandRequest, query *historyQuery, responseURL string, replaceOriginal bool) {
+	filter, page, err := parseHistoryArguments(query.Arguments)
+	if err != nil {
+		self.sendSlackResponse(err.Error(), responseURL)
+		return
+	}
+	filter.Kind = query.Kind
+	if query.Page > 0 {
+		page = query.Page
+	}
+
+	records, err := self.history.List()
+	if err != nil {
+		log.Printf("Error when listing history : %s", err.Error())
+		self.sendSlackResponse("I could not read the history : "+err.Error(), responseURL)
+		return
+	}
+
+	records = JobHistory.FilterRecords(records, filter)
+	if filter.Environment == "" {
+		records = self.authorizedRecords(request, records)
+	}
+	pageCount := (len(records) + historyPageSize - 1) / historyPageSize
+	if pageCount == 0 {
+		self.sendSlackResponse("No "+query.Kind+" matches your filters", responseURL)
+		return
+	}
+	if page > pageCount {
+		page = pageCount
+	}
+	query.Page = page
+
+	first := (page - 1) * historyPageSize
+	last := first + historyPageSize
+	if last > len(records) {
+		last = len(records)
+	}
+
+	text := fmt.Sprintf("%s history, page %d/%d (%d runs)", strings.Title(query.Kind), page, pageCount, len(records))
+	blocks := []slack.Block{slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, text, false, false))}
+	for _, record := range records[first:last] {
+		blocks = append(blocks, historyRecordBlock(record), slack.NewDividerBlock())
+	}
+
+	var buttons []slack.BlockElement
+	if page > 1 {
+		buttons = append(buttons, historyPageButton(query, page-1, "Previous"))
+	}
+	if page < pageCount {
+		buttons = append(buttons, historyPageButton(query, page+1, "Next"))
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, slack.NewActionBlock("history-pagination", buttons...))
+	}
+
+	self.sendSlackResponseBlocks(text, blocks, responseURL, replaceOriginal)
+}
+
+//authorizedRecords drops the records of the environments request is not allowed on.
+func (self *Server) authorizedRecords(request *CommandRequest, records []*JobHistory.Record) []*JobHistory.Record {
+	allowed := map[string]bool{}
+	authorized := []*JobHistory.Record{}
+	for _, record := range records {
+		isAllowed, checked := allowed[record.Environment]
+		if !checked {
+			isAllowed = self.authorize(request, record.Environment) == nil
+			allowed[record.Environment] = isAllowed
+		}
+		if isAllowed {
+			authorized = append(authorized, record)
+		}
+	}
+	return authorized
+}
+
+func historyRecordBlock(record *JobHistory.Record) slack.Block {
+	duration := "-"
+	if record.FinishedAt != nil {
+		duration = record.FinishedAt.Sub(record.StartedAt).Round(time.Second).String()
+	}
+	exitCode := "-"
+	if record.ExitCode != nil {
+		exitCode = strconv.Itoa(int(*record.ExitCode))
+	}
+
+	fields := []*slack.TextBlockObject{
+		slack.NewTextBlockObject(slack.MarkdownType, "*Run*\n`"+record.ID+"`", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Status*\n"+phaseEmoji(record.Phase)+" "+record.Phase+" (exit code "+exitCode+")", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Environment / Service*\n"+record.Environment+" / "+record.Service, false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Version / Name*\n"+record.Version+" / "+record.MigrationName, false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Requester*\n<@"+record.RequesterID+">", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*Started*\n<!date^%d^{date_short_pretty} {time}|%s> (%s)", record.StartedAt.Unix(), record.StartedAt.Format(time.RFC3339), duration), false, false),
+	}
+	return slack.NewSectionBlock(nil, fields, nil)
+}
+
+func historyPageButton(query *historyQuery, page int, label string) slack.BlockElement {
+	pageQuery := *query
+	pageQuery.Page = page
+	value, _ := json.Marshal(pageQuery)
+	return slack.NewButtonBlockElement(historyPageActionID, string(value), slack.NewTextBlockObject(slack.PlainTextType, label, false, false))
+}
+
+//parseHistoryArguments reads the key:value filters of a history command.
+//@returns (*JobHistory.Filter, int, error): the filter, the asked page and error on unknown or malformed filter.
+func parseHistoryArguments(arguments []string) (*JobHistory.Filter, int, error) {
+	filter := &JobHistory.Filter{}
+	page := 1
+
+	for _, argument := range arguments {
+		parts := strings.SplitN(argument, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, 0, errors.New("Unknown history filter " + argument + ", filters are written as key:value")
+		}
+
+		key, value := parts[0], parts[1]
+		switch key {
+		case "env", "environment":
+			filter.Environment = value
+		case "user":
+			if strings.HasPrefix(value, "<@") {
+				filter.RequesterID = strings.SplitN(strings.Trim(value, "<@>"), "|", 2)[0]
+			} else {
+				filter.RequesterName = strings.TrimPrefix(value, "@")
+			}
+		case "status":
+			filter.Phase = value
+		case "since", "until":
+			date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return nil, 0, errors.New("Dates must be written as YYYY-MM-DD : " + value)
+			}
+			if key == "since" {
+				filter.Since = date
+			} else {
+				filter.Until = date.AddDate(0, 0, 1)
+			}
+		case "page":
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 1 {
+				return nil, 0, errors.New("Page must be a positive number : " + value)
+			}
+			page = number
+		default:
+			return nil, 0, errors.New("Unknown history filter " + key + ", use one of env, user, status, since, until, page")
+		}
+	}
+	return filter, page, nil
+}
+
+func phaseEmoji(phase string) string {
+	switch phase {
+	case JobHistory.PhaseSucceeded:
+		return ":white_check_mark:"
+	case JobHistory.PhaseFailed:
+		return ":x:"
+	case JobHistory.PhaseCancelled:
+		return ":no_entry_sign:"
+	case "Running":
+		return ":hourglass_flowing_sand:"
+	default:
+		return ":hourglass:"
+	}
+}
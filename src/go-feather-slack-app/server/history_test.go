@@ -0,0 +1,139 @@
+/**
+ * File              : history_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+)
+
+func numberedLines(from int, to int) string {
+	lines := []string{}
+	for index := from; index <= to; index++ {
+		lines = append(lines, "line "+strconv.Itoa(index))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLogExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		logs string
+		want string
+	}{
+		{name: "empty", logs: "", want: ""},
+		{name: "single line", logs: "done\n", want: "done"},
+		{name: "trailing new lines are dropped", logs: "first\nsecond\n\n\n", want: "first\nsecond"},
+		{name: "at max lines", logs: numberedLines(1, logExcerptLines), want: numberedLines(1, logExcerptLines)},
+		{name: "over max lines keeps the last ones", logs: numberedLines(1, logExcerptLines+5) + "\n", want: numberedLines(6, logExcerptLines+5)},
+		{name: "over max bytes keeps the end", logs: strings.Repeat("a", logExcerptMaxBytes) + "end", want: strings.Repeat("a", logExcerptMaxBytes-3) + "end"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := logExcerpt(test.logs)
+			if got != test.want {
+				t.Errorf("Expected %q, got %q", test.want, got)
+			}
+			if len(got) > logExcerptMaxBytes {
+				t.Errorf("Expected at most %d bytes, got %d", logExcerptMaxBytes, len(got))
+			}
+		})
+	}
+}
+
+func TestSaveRunRecord(t *testing.T) {
+	finishedAt := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		stored *JobHistory.Record
+		want   JobHistory.Record
+	}{
+		{
+			name:   "not cancelled",
+			stored: &JobHistory.Record{ID: "run1", Phase: "Pending"},
+			want:   JobHistory.Record{ID: "run1", Phase: "Running"},
+		},
+		{
+			name:   "cancelled by another replica",
+			stored: &JobHistory.Record{ID: "run1", Phase: JobHistory.PhaseCancelled, CancelledBy: "U0CANCEL", FinishedAt: &finishedAt},
+			want:   JobHistory.Record{ID: "run1", Phase: JobHistory.PhaseCancelled, CancelledBy: "U0CANCEL", FinishedAt: &finishedAt},
+		},
+		{
+			name:   "being cancelled by another replica",
+			stored: &JobHistory.Record{ID: "run1", Phase: "Pending", CancelledBy: "U0CANCEL"},
+			want:   JobHistory.Record{ID: "run1", Phase: "Running", CancelledBy: "U0CANCEL"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := JobHistory.NewFileStore(filepath.Join(t.TempDir(), "history.json"), 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save(test.stored); err != nil {
+				t.Fatal(err)
+			}
+			server := &Server{history: store}
+
+			record := &JobHistory.Record{ID: "run1", Phase: "Running"}
+			server.saveRunRecord(record)
+
+			got, err := store.Get("run1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Phase != test.want.Phase || got.CancelledBy != test.want.CancelledBy || (got.FinishedAt == nil) != (test.want.FinishedAt == nil) {
+				t.Errorf("Expected %+v, got %+v", test.want, *got)
+			}
+			if record.CancelledBy != "" || record.Phase != "Running" {
+				t.Errorf("Expected the followed record to be left as is, got %+v", *record)
+			}
+		})
+	}
+}
+
+func TestAuthorizedRecords(t *testing.T) {
+	server := &Server{}
+	server.config.AUTHORIZATION.Rules = []*AuthorizationRule{
+		{Commands: []string{"/migration"}, Environments: []string{"prod"}, Users: []string{"U0ADMIN"}},
+	}
+	records := []*JobHistory.Record{
+		{ID: "run1", Environment: "staging"},
+		{ID: "run2", Environment: "prod"},
+		{ID: "run3", Environment: "staging"},
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		want   []string
+	}{
+		{name: "allowed everywhere", userID: "U0ADMIN", want: []string{"run1", "run2", "run3"}},
+		{name: "not allowed on prod", userID: "U0DEV", want: []string{"run1", "run3"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := &CommandRequest{Command: "/migration", Subcommand: "history", UserID: test.userID}
+			got := []string{}
+			for _, record := range server.authorizedRecords(request, records) {
+				got = append(got, record.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("Expected runs %v, got %v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+/**
+ * File              : interactions.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/slack-go/slack"
+)
+
+//ActionHandler handles a click on a Block Kit button whose action_id it has been registered for.
+//It is called in its own goroutine and must answer through the callback ResponseURL.
+type ActionHandler func(callback *slack.InteractionCallback, action *slack.BlockAction)
+
+func (self *Server) registerActions() {
+	self.actions = map[string]ActionHandler{
+		approveJobActionID:  self.handleApprovalAction,
+		rejectJobActionID:   self.handleApprovalAction,
+		historyPageActionID: self.handleHistoryPageAction,
+		cancelRunActionID:   self.handleCancelRunAction,
+		retryRunActionID:    self.handleRetryRunAction,
+	}
+}
+
+func (self *Server) handleSlackInteraction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		verifier, err := slack.NewSecretsVerifier(r.Header, self.config.SLACK_SIGNING_SECRET)
+		if err != nil {
+			log.Println("Error creating NewSecretVerifier: ", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(io.TeeReader(r.Body, &verifier))
+		if err := r.ParseForm(); err != nil {
+			log.Println("Error parsing interaction form: ", err.Error())
+			sendStatusInternalError(w)
+			return
+		}
+
+		if err := verifier.Ensure(); err != nil {
+			log.Println("Error verifying slack request signature: ", err.Error())
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var callback slack.InteractionCallback
+		if err := json.Unmarshal([]byte(r.FormValue("payload")), &callback); err != nil {
+			log.Println("Error parsing interaction payload: ", err.Error())
+			sendStatusInternalError(w)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if callback.Type != slack.InteractionTypeBlockActions {
+			log.Printf("Ignoring interaction of type %s", callback.Type)
+			return
+		}
+
+		for _, action := range callback.ActionCallback.BlockActions {
+			handler, ok := self.actions[action.ActionID]
+			if !ok {
+				log.Printf("No handler registered for action %s", action.ActionID)
+				continue
+			}
+			if !self.inflight.start(func() { handler(&callback, action) }) {
+				self.sendSlackResponse(restartingMessage, callback.ResponseURL)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/**
+ * File              : leader.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultLeaseName     = "go-feather-slack-app"
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+	//adoptionRetryPeriod is the delay before watching the jobs of a namespace again after an error.
+	adoptionRetryPeriod = 10 * time.Second
+)
+
+//HighAvailabilityConfig enables running several replicas, a Lease electing the one following the jobs.
+type HighAvailabilityConfig struct {
+	Enabled bool `json:"enabled"`
+	//LeaseName and LeaseNamespace locate the Lease, POD_NAMESPACE (or default) is used when no namespace is given.
+	LeaseName      string `json:"leaseName"`
+	LeaseNamespace string `json:"leaseNamespace"`
+	//Identity of this replica in the Lease, POD_NAME (or the hostname) by default.
+	Identity      string          `json:"identity"`
+	LeaseDuration metav1.Duration `json:"leaseDuration"`
+	RenewDeadline metav1.Duration `json:"renewDeadline"`
+	RetryPeriod   metav1.Duration `json:"retryPeriod"`
+}
+
+//validate checks that the replicas share the history, the runs and their approvals are resolved from it by any replica.
+//@returns error if high availability is enabled with a history kept on the disk of each replica.
+func (self HighAvailabilityConfig) validate(history JobHistory.Config) error {
+	if !self.Enabled || history.Backend == JobHistory.ConfigMapBackend || history.Backend == JobHistory.SecretBackend {
+		return nil
+	}
+	return errors.New("highAvailability needs a history shared by the replicas, set history.backend to configmap or secret")
+}
+
+//leaderElectionOptions fills the missing settings with their defaults.
+func (self HighAvailabilityConfig) leaderElectionOptions() PodManager.LeaderElectionOptions {
+	options := PodManager.LeaderElectionOptions{
+		LeaseName:      self.LeaseName,
+		LeaseNamespace: self.LeaseNamespace,
+		Identity:       self.Identity,
+		LeaseDuration:  self.LeaseDuration.Duration,
+		RenewDeadline:  self.RenewDeadline.Duration,
+		RetryPeriod:    self.RetryPeriod.Duration,
+	}
+	if options.LeaseName == "" {
+		options.LeaseName = defaultLeaseName
+	}
+	if options.LeaseNamespace == "" {
+		options.LeaseNamespace = os.Getenv("POD_NAMESPACE")
+	}
+	if options.LeaseNamespace == "" {
+		options.LeaseNamespace = "default"
+	}
+	if options.Identity == "" {
+		options.Identity = os.Getenv("POD_NAME")
+	}
+	if options.Identity == "" {
+		options.Identity, _ = os.Hostname()
+	}
+	if options.LeaseDuration <= 0 {
+		options.LeaseDuration = defaultLeaseDuration
+	}
+	if options.RenewDeadline <= 0 {
+		options.RenewDeadline = defaultRenewDeadline
+	}
+	if options.RetryPeriod <= 0 {
+		options.RetryPeriod = defaultRetryPeriod
+	}
+	return options
+}
+
+//leadership tells whether this replica follows the jobs, a single replica always does.
+type leadership struct {
+	mutex  sync.Mutex
+	leader bool
+	since  time.Time
+}
+
+func (self *leadership) set(leader bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.leader = leader
+	self.since = time.Now()
+}
+
+func (self *leadership) isLeader() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.leader
+}
+
+//leadingSince returns when this replica became the leader.
+func (self *leadership) leadingSince() time.Time {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.since
+}
+
+//runLeaderElection competes for the Lease until ctx is cancelled, the leader follows the jobs of every replica.
+func (self *Server) runLeaderElection(ctx context.Context) {
+	options := self.config.HIGH_AVAILABILITY.leaderElectionOptions()
+	log.Printf("Competing for lease %s/%s as %s", options.LeaseNamespace, options.LeaseName, options.Identity)
+	if err := self.manager.RunLeaderElection(ctx, options, self.startLeading, self.stopLeading); err != nil {
+		log.Panicln("Error when setting up leader election : " + err.Error())
+	}
+}
+
+//startLeading takes over the runs of every labeled Job, whichever replica created it, and expires the overdue approvals until ctx is cancelled.
+func (self *Server) startLeading(ctx context.Context) {
+	log.Println("This replica is now the leader, following the jobs")
+	self.leadership.set(true)
+	for _, namespace := range self.config.namespaces() {
+		go self.adoptRuns(ctx, namespace)
+	}
+	go self.sweepApprovals(ctx)
+}
+
+//stopLeading stops following the runs without reporting their end, the new leader takes them over.
+func (self *Server) stopLeading() {
+	if !self.leadership.isLeader() {
+		return
+	}
+	log.Println("This replica is not the leader anymore, releasing its runs")
+	self.leadership.set(false)
+	self.runs.releaseAll()
+}
+
+//adoptRuns follows the runs of the labeled Jobs of a namespace, those created by other replicas included.
+func (self *Server) adoptRuns(ctx context.Context, namespace string) {
+	for ctx.Err() == nil {
+		err := self.manager.WatchRunJobs(ctx, namespace, func(job *batchv1.Job) {
+			notice := ""
+			if job.CreationTimestamp.Time.Before(self.leadership.leadingSince()) {
+				notice = ":arrows_counterclockwise: Following job " + job.Name + " again after a restart or a leader change"
+			}
+			self.adoptRun(job, notice)
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error when watching jobs in namespace %s : %s", namespace, err.Error())
+			select {
+			case <-ctx.Done():
+			case <-time.After(adoptionRetryPeriod):
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+/**
+ * File              : leader_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"testing"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+)
+
+func TestHighAvailabilityValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		backend string
+		wantErr bool
+	}{
+		{name: "disabled with file history", enabled: false, backend: JobHistory.FileBackend},
+		{name: "disabled with default history", enabled: false, backend: ""},
+		{name: "enabled with configmap history", enabled: true, backend: JobHistory.ConfigMapBackend},
+		{name: "enabled with secret history", enabled: true, backend: JobHistory.SecretBackend},
+		{name: "enabled with file history", enabled: true, backend: JobHistory.FileBackend, wantErr: true},
+		{name: "enabled with default history", enabled: true, backend: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := HighAvailabilityConfig{Enabled: test.enabled}.validate(JobHistory.Config{Backend: test.backend})
+			if (err != nil) != test.wantErr {
+				t.Errorf("Expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
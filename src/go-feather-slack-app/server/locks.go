@@ -0,0 +1,97 @@
+/**
+ * File              : locks.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
+)
+
+//invalidLockNameCharacters are replaced in environment names so they can be used in a Lease name.
+var invalidLockNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+//lockName returns the name of the Lease locking an environment.
+func lockName(environment string) string {
+	return "go-feather-slack-app-lock-" + invalidLockNameCharacters.ReplaceAllString(strings.ToLower(environment), "-")
+}
+
+//usesLock tells whether the jobs of the environment must not run concurrently.
+func (self *Server) usesLock(environmentName string) bool {
+	environment, ok := self.config.ENVIRONMENTS[environmentName]
+	return ok && !environment.AllowConcurrentJobs
+}
+
+//lockedError tells who holds the lock of the environment and since when.
+func lockedError(environment string, holder PodManager.LockHolder) error {
+	return fmt.Errorf("*%s* is locked by <@%s> running `%s` (run `%s`) since <!date^%d^{date_short_pretty} {time}|%s>, try again once it has ended",
+		environment, holder.RequesterID, holder.Command, holder.RunID, holder.Since.Unix(), holder.Since.Format(time.RFC3339))
+}
+
+//checkLock rejects a job on a locked environment before it is approved or created, the lock is only taken once it is.
+//@returns error naming who holds the lock and since when if another run holds it.
+func (self *Server) checkLock(payload *JobCreationPayload) error {
+	if !self.usesLock(payload.Environment) {
+		return nil
+	}
+
+	holder, err := self.manager.GetLockHolder(payload.Namespace, lockName(payload.Environment))
+	if err != nil {
+		return errors.New("I could not check the lock of " + payload.Environment + " : " + err.Error())
+	}
+	if holder != nil {
+		return lockedError(payload.Environment, *holder)
+	}
+	return nil
+}
+
+//acquireLock locks the environment of the run until its Job ends.
+//@returns error naming who holds the lock and since when if another run holds it.
+func (self *Server) acquireLock(record *JobHistory.Record) error {
+	if !self.usesLock(record.Environment) {
+		return nil
+	}
+
+	holder := PodManager.LockHolder{RunID: record.ID, RequesterID: record.RequesterID, Command: record.Command, Since: record.StartedAt}
+	err := self.manager.AcquireLock(record.Namespace, lockName(record.Environment), holder)
+	var locked *PodManager.LockedError
+	if errors.As(err, &locked) {
+		return lockedError(record.Environment, locked.Holder)
+	} else if err != nil {
+		return errors.New("I could not lock " + record.Environment + " : " + err.Error())
+	}
+	return nil
+}
+
+//releaseLock unlocks the environment of the run if the run holds its lock.
+//A wait strategy may stop following a run whose Job is still running, the lock is then kept
+//and taken over by the next run once the Job has ended.
+func (self *Server) releaseLock(record *JobHistory.Record) {
+	if !self.usesLock(record.Environment) {
+		return
+	}
+
+	running, err := self.manager.HasUnfinishedJob(record.Namespace, record.ID)
+	if err != nil {
+		log.Printf("Error when checking whether run %s has a running job, keeping lock of %s : %s", record.ID, record.Environment, err.Error())
+		return
+	}
+	if running {
+		log.Printf("Keeping lock of %s until the job of run %s has ended", record.Environment, record.ID)
+		return
+	}
+	if err := self.manager.ReleaseLock(record.Namespace, lockName(record.Environment), record.ID); err != nil {
+		log.Printf("Error when releasing lock of %s held by run %s : %s", record.Environment, record.ID, err.Error())
+	}
+}
@@ -0,0 +1,32 @@
+/**
+ * File              : locks_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import "testing"
+
+func TestLockName(t *testing.T) {
+	tests := []struct {
+		environment string
+		want        string
+	}{
+		{environment: "prod", want: "go-feather-slack-app-lock-prod"},
+		{environment: "Prod", want: "go-feather-slack-app-lock-prod"},
+		{environment: "eu-west.prod", want: "go-feather-slack-app-lock-eu-west.prod"},
+		{environment: "prod_eu", want: "go-feather-slack-app-lock-prod-eu"},
+		{environment: "prod eu / 2", want: "go-feather-slack-app-lock-prod-eu-2"},
+		{environment: "préprod", want: "go-feather-slack-app-lock-pr-prod"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.environment, func(t *testing.T) {
+			if got := lockName(test.environment); got != test.want {
+				t.Errorf("Expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
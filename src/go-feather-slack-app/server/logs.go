@@ -0,0 +1,295 @@
+/**
+ * File              : logs.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultLogsFlushInterval    = 5 * time.Second
+	defaultLogsMaxBufferedLines = 2000
+	defaultLogsUploadThreshold  = 8000
+	defaultLogsTailLines        = 20
+	//maxLogMessageSize keeps each posted batch well under the Slack message size limit.
+	maxLogMessageSize = 3500
+)
+
+//LogsConfig tunes how pod logs are streamed into the run thread.
+type LogsConfig struct {
+	//FlushInterval is the delay between two posts of buffered log lines.
+	FlushInterval metav1.Duration `json:"flushInterval"`
+	//MaxBufferedLines bounds the lines waiting to be posted, the oldest ones are skipped above it.
+	MaxBufferedLines int `json:"maxBufferedLines"`
+	//UploadThreshold is the logs size (in bytes) above which logs are uploaded as a file instead of being posted.
+	UploadThreshold int `json:"uploadThreshold"`
+	//TailLines is the count of last lines shown along with uploaded logs, error lines are always shown.
+	TailLines int `json:"tailLines"`
+	//Redact are regular expressions of secrets to hide from logs, on top of the builtin ones.
+	Redact []string `json:"redact"`
+}
+
+//errorLinePattern matches log lines worth showing even when they are not part of the logs tail.
+var errorLinePattern = regexp.MustCompile(`(?i)\b(error|exception|fatal|failed)\b`)
+
+func (self LogsConfig) uploadThreshold() int {
+	if self.UploadThreshold <= 0 {
+		return defaultLogsUploadThreshold
+	}
+	return self.UploadThreshold
+}
+
+func (self LogsConfig) tailLines() int {
+	if self.TailLines <= 0 {
+		return defaultLogsTailLines
+	}
+	return self.TailLines
+}
+
+//postLogs posts the logs of a run in its thread,
+//logs larger than the upload threshold are uploaded as a file and only their tail and error lines are posted.
+func (self *Server) postLogs(record *JobHistory.Record, logs string) {
+	if strings.TrimSpace(logs) == "" {
+		return
+	}
+
+	if len(logs) <= self.config.LOGS.uploadThreshold() {
+		self.sendSlackMessageWithClient(record.ChannelID, "```"+logs+"```", record.ThreadTs)
+		return
+	}
+
+	//files.upload is retired, the file goes through files.getUploadURLExternal and files.completeUploadExternal.
+	_, err := self.slackClient.UploadFileV2(slack.UploadFileV2Parameters{
+		Reader:          strings.NewReader(logs),
+		FileSize:        len(logs),
+		Filename:        record.JobName + ".log",
+		Title:           "Logs of " + record.JobName,
+		Channel:         record.ChannelID,
+		ThreadTimestamp: record.ThreadTs,
+	})
+	if err != nil {
+		log.Printf("Error when uploading logs of run %s : %s", record.ID, err.Error())
+	}
+
+	message := "Last lines of the logs"
+	if err != nil {
+		message = "Logs could not be uploaded (" + err.Error() + "), last lines of the logs"
+	}
+	self.sendSlackMessageWithClient(record.ChannelID, message+" :\n```"+logTail(logs, self.config.LOGS.tailLines())+"```", record.ThreadTs)
+}
+
+//logTail keeps the last tailLines lines of logs, preceded by the error lines found before them.
+func logTail(logs string, tailLines int) string {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	start := len(lines) - tailLines
+	if start < 0 {
+		start = 0
+	}
+
+	kept := []string{}
+	for _, line := range lines[:start] {
+		if errorLinePattern.MatchString(line) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) > tailLines {
+		kept = kept[len(kept)-tailLines:]
+	}
+	if len(kept) > 0 {
+		kept = append(kept, "[...]")
+	}
+
+	tail := strings.Join(append(kept, lines[start:]...), "\n")
+	if len(tail) > maxLogMessageSize {
+		start := len(tail) - maxLogMessageSize
+		for start < len(tail) && !utf8.RuneStart(tail[start]) {
+			start++
+		}
+		tail = tail[start:]
+	}
+	return tail
+}
+
+//logStreamer batches the log lines of a run and posts them in its thread every flush interval.
+//Only one batch is posted per interval (longer when Slack rate limits us) so slow posts
+//never block the pod logs stream, lines pile up in a bounded buffer instead.
+type logStreamer struct {
+	server   *Server
+	record   *JobHistory.Record
+	interval time.Duration
+	maxLines int
+
+	mutex      sync.Mutex
+	lines      []string
+	skipped    int
+	dropped    bool
+	postedSize int
+	//truncated is set once the logs are too large to be streamed, lines are then only buffered by the caller.
+	truncated bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+//newLogStreamer starts streaming log lines into the thread of the run.
+func (self *Server) newLogStreamer(record *JobHistory.Record) *logStreamer {
+	streamer := &logStreamer{
+		server:   self,
+		record:   record,
+		interval: self.config.LOGS.FlushInterval.Duration,
+		maxLines: self.config.LOGS.MaxBufferedLines,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if streamer.interval <= 0 {
+		streamer.interval = defaultLogsFlushInterval
+	}
+	if streamer.maxLines <= 0 {
+		streamer.maxLines = defaultLogsMaxBufferedLines
+	}
+
+	go streamer.loop()
+	return streamer
+}
+
+//write buffers a log line until the next flush, lines too long to be posted are split.
+func (self *logStreamer) write(line string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	self.lines = append(self.lines, splitLogLine(line, maxLogMessageSize)...)
+	if overflow := len(self.lines) - self.maxLines; overflow > 0 {
+		self.lines = self.lines[overflow:]
+		self.skipped += overflow
+		self.dropped = true
+	}
+}
+
+//splitLogLine splits line in parts of at most maxSize bytes, without splitting UTF-8 characters.
+func splitLogLine(line string, maxSize int) []string {
+	parts := []string{}
+	for len(line) > maxSize {
+		end := maxSize
+		for end > 0 && !utf8.RuneStart(line[end]) {
+			end--
+		}
+		if end == 0 {
+			end = maxSize
+		}
+		parts = append(parts, line[:end])
+		line = line[end:]
+	}
+	return append(parts, line)
+}
+
+//close posts the remaining lines and stops the streamer.
+//@returns bool: true if the whole logs have been posted in the thread, none being skipped.
+func (self *logStreamer) close() bool {
+	close(self.stop)
+	<-self.done
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.postedSize > 0 && !self.truncated && !self.dropped
+}
+
+func (self *logStreamer) loop() {
+	defer close(self.done)
+
+	wait := self.interval
+	for {
+		select {
+		case <-self.stop:
+			for self.pending() {
+				if wait := self.flush(); self.pending() {
+					time.Sleep(wait)
+				}
+			}
+			return
+		case <-time.After(wait):
+			wait = self.flush()
+		}
+	}
+}
+
+func (self *logStreamer) pending() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return len(self.lines) > 0 || self.skipped > 0
+}
+
+//flush posts a batch of buffered lines, at most maxLogMessageSize long.
+//@returns time.Duration: the delay before the next flush, the one asked by Slack when rate limited.
+func (self *logStreamer) flush() time.Duration {
+	self.mutex.Lock()
+	if self.truncated {
+		self.lines, self.skipped = nil, 0
+		self.mutex.Unlock()
+		return self.interval
+	}
+	size, count := 0, 0
+	for count < len(self.lines) && (count == 0 || size+len(self.lines[count]) < maxLogMessageSize) {
+		size += len(self.lines[count]) + 1
+		count++
+	}
+	batch, skipped := self.lines[:count], self.skipped
+	self.lines, self.skipped = self.lines[count:], 0
+	self.mutex.Unlock()
+
+	if len(batch) == 0 && skipped == 0 {
+		return self.interval
+	}
+
+	if self.postedSize+size > self.server.config.LOGS.uploadThreshold() {
+		self.mutex.Lock()
+		self.truncated = true
+		self.mutex.Unlock()
+		self.server.sendSlackMessageWithClient(self.record.ChannelID, "_Logs are too large to be streamed, they will be uploaded as a file once the job ends_", self.record.ThreadTs)
+		return self.interval
+	}
+
+	message := ""
+	if skipped > 0 {
+		message = "_" + strconv.Itoa(skipped) + " log lines skipped_\n"
+	}
+	if len(batch) > 0 {
+		message += "```" + strings.Join(batch, "\n") + "```"
+	}
+
+	_, err := self.server.sendSlackMessageWithClient(self.record.ChannelID, message, self.record.ThreadTs)
+	if rateLimited, ok := err.(*slack.RateLimitedError); ok {
+		self.mutex.Lock()
+		self.lines = append(append([]string{}, batch...), self.lines...)
+		self.skipped += skipped
+		self.mutex.Unlock()
+		return rateLimited.RetryAfter
+	}
+	if err != nil {
+		//The batch is lost, the whole logs are then uploaded once the attempt ends.
+		log.Printf("Error when posting logs of run %s : %s", self.record.ID, err.Error())
+		self.mutex.Lock()
+		self.dropped = true
+		self.mutex.Unlock()
+		return self.interval
+	}
+
+	self.mutex.Lock()
+	self.postedSize += size
+	self.mutex.Unlock()
+	return self.interval
+}
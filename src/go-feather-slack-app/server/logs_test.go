@@ -0,0 +1,87 @@
+/**
+ * File              : logs_test.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	"github.com/slack-go/slack"
+)
+
+func TestSplitLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "empty", line: "", want: []string{""}},
+		{name: "short", line: "Migrating", want: []string{"Migrating"}},
+		{name: "at max size", line: "0123456789", want: []string{"0123456789"}},
+		{name: "over max size", line: "0123456789abcdefghijk", want: []string{"0123456789", "abcdefghij", "k"}},
+		{name: "multi-byte characters are kept whole", line: "012345678é9", want: []string{"012345678", "é9"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := splitLogLine(test.line, 10); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLogStreamerWriteSplitsLongLines(t *testing.T) {
+	streamer := &logStreamer{maxLines: defaultLogsMaxBufferedLines}
+	streamer.write(strings.Repeat("é", 1024*1024/2))
+
+	if len(streamer.lines) < 2 {
+		t.Fatalf("Expected a 1 MiB line to be split, got %d lines", len(streamer.lines))
+	}
+	for _, line := range streamer.lines {
+		if len(line) > maxLogMessageSize || !utf8.ValidString(line) {
+			t.Fatalf("Expected valid lines of at most %d bytes, got %d bytes", maxLogMessageSize, len(line))
+		}
+	}
+}
+
+func TestLogStreamerClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     bool
+	}{
+		{name: "lines posted", response: `{"ok":true,"channel":"C0ANSWER","ts":"1.3"}`, want: true},
+		{name: "post failed", response: `{"ok":false,"error":"channel_not_found"}`, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(test.response))
+			}))
+			defer slackAPI.Close()
+
+			server := &Server{slackClient: *slack.New("xoxb-token", slack.OptionAPIURL(slackAPI.URL+"/"))}
+			server.config.LOGS.FlushInterval.Duration = time.Hour
+			streamer := server.newLogStreamer(&JobHistory.Record{ID: "run1", ChannelID: "C0ANSWER", ThreadTs: "1.2"})
+			streamer.write("Migrating add-users")
+
+			if got := streamer.close(); got != test.want {
+				t.Errorf("Expected close to tell the whole logs have been posted %v, got %v", test.want, got)
+			}
+		})
+	}
+}
@@ -2,90 +2,245 @@
  * File              : main.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 09.12.2020
- * Last Modified Date: 18.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package server
 
 import (
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
 	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
 	"github.com/slack-go/slack"
+	v1 "k8s.io/api/core/v1"
 )
 
 func healthz(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (self *Server) fromSlackTextToStruct(commandName string, slackTextArguments []string, structHandler *JobCreationPayload) error {
-	dockerTag := slackTextArguments[0]
-
-	structHandler.DockerImage = self.config.DOCKER_IMAGE + ":" + dockerTag
-	structHandler.Namespace = "default"
-	structHandler.JobName = "go-feather-slack-app-" + strconv.Itoa(int(time.Now().Unix()))
-	structHandler.EnvVariablesMap = make(map[string]string)
-
-	if commandName == self.config.MIGRATION_COMMAND {
-		structHandler.EnvVariablesMap[self.config.SEQUELIZE_MIGRATION_ENV_NAME] = slackTextArguments[1]
-		structHandler.ConfigMapsNames = slackTextArguments[2:]
-	} else if commandName == self.config.SEED_COMMAND {
-		structHandler.EnvVariablesMap[self.config.SEQUELIZE_SEED_ENV_NAME] = slackTextArguments[1]
-		structHandler.ConfigMapsNames = slackTextArguments[2:]
+// SubmitJobCreation creates the Job built by a command handler and follows it until its end.
+// It is meant to be run in its own goroutine since it blocks until the pod ends,
+// the slash command responseURL is only used until the Job is created, the run is then followed in the answer channel thread.
+//@args request: the command request which asked for the Job
+//@args FormValues: the Job description returned by the command handler
+func (self *Server) SubmitJobCreation(request *CommandRequest, FormValues *JobCreationPayload) {
+	responseURL := request.ResponseURL
+	if FormValues.Kind == jobKindSeed {
+		self.increaseSeedLaunched()
 	} else {
-		return errors.New("Neither migration nor seed command has been provided")
+		self.increaseMigrationLaunched()
 	}
 
-	return nil
-}
+	record := self.newRunRecord(request, FormValues)
+	if err := self.acquireLock(record); err != nil {
+		log.Printf("Run %s of %s rejected : %s", record.ID, record.Environment, err.Error())
+		self.sendSlackResponse(err.Error(), responseURL)
+		return
+	}
+	self.saveRecord(record)
 
-func (self *Server) SubmitJobCreation(commandName string, slackTextArguments []string, w http.ResponseWriter, r *http.Request) {
-	var FormValues JobCreationPayload
+	//The status message is posted first, its thread is kept in the Job labels so the run can be recovered after a restart.
+	threadTs, err := self.postRunStatus(record)
+	if err != nil {
+		log.Printf("Error when posting message on slack for run %s : %s", record.ID, err.Error())
+		self.finishRecord(record, JobHistory.PhaseFailed, err.Error())
+		self.sendSlackResponse("I could not post on the answer channel, the job has not been created : "+err.Error(), responseURL)
+		return
+	}
+
+	record.ThreadTs = threadTs
+	self.saveRecord(record)
 
-	if err := self.fromSlackTextToStruct(commandName, slackTextArguments, &FormValues); err != nil {
-		SendSlackMessage("An error occured while unmarchalling your payload : "+err.Error(), w)
+	if record.RetryOf != "" {
+		self.linkRetriedRun(record)
 	}
 
 	configMapRefs := self.manager.CreateConfigRefSpec(FormValues.ConfigMapsNames)
 	envMapRefs := self.manager.CreateEnvsRefSpec(FormValues.EnvVariablesMap)
 	prefixName := FormValues.JobName + "-job"
-	jobSpec := self.manager.CreateJobSpec("go-feather-slack-app-job", prefixName, FormValues.DockerImage, envMapRefs, configMapRefs)
-	pod, err := self.manager.CreateJob(FormValues.Namespace, prefixName, *jobSpec)
-	threadTs, err := self.sendSlackMessageWithClient("Creation of job "+pod.Name, "")
-
+	jobOptions := PodManager.JobSpecOptions{
+		ServiceAccountName:      FormValues.ServiceAccount,
+		ActiveDeadlineSeconds:   FormValues.DeadlineSeconds,
+		BackoffLimit:            FormValues.BackoffLimit,
+		TTLSecondsAfterFinished: FormValues.TTLSeconds,
+		RestartPolicy:           v1.RestartPolicy(FormValues.RestartPolicy),
+	}
+	runInfo := PodManager.RunInfo{ID: record.ID, ChannelID: record.ChannelID, ThreadTs: record.ThreadTs, Environment: record.Environment, Command: record.Command, RequesterID: record.RequesterID}
+	jobSpec := self.manager.CreateJobSpec("go-feather-slack-app-job", prefixName, FormValues.DockerImage, envMapRefs, configMapRefs, jobOptions)
+	//The run is reserved so the leader does not adopt the Job while this replica is creating it.
+	self.runs.reserve(record.ID)
+	pod, err := self.manager.CreateJob(FormValues.Namespace, prefixName, *jobSpec, runInfo)
 	if err != nil {
+		self.runs.finish(record.ID)
 		log.Printf("Error during creation of Job: %s", err.Error())
-		SendSlackMessage("Error during creation of Job: "+err.Error(), w)
+		self.finishRecord(record, JobHistory.PhaseFailed, err.Error())
+		self.updateRunStatus(record)
+		self.sendSlackMessageWithClient(record.ChannelID, ":x: Error during creation of Job: "+err.Error(), record.ThreadTs)
+		self.sendSlackResponse("Error during creation of Job: "+err.Error(), responseURL)
 		return
 	}
 
-	self.sendSlackMessageWithClient("Job has been created, I'll send logs when finished", threadTs)
-	self.sendSlackMessageWithClient("Image :"+FormValues.DockerImage, threadTs)
-	self.FetchJobPodLogs(FormValues.Namespace, pod.Name, threadTs)
-	SendSlackMessage("Job has been created", w)
+	record.JobName = pod.Labels["job-name"]
+	record.PodName = pod.Name
+	record.Phase = string(pod.Status.Phase)
+	self.saveRecord(record)
+	self.updateRunStatus(record)
+
+	self.sendSlackResponse("Job "+pod.Name+" (run "+record.ID+") has been created, follow it on <#"+FormValues.AnswerChannel+">", responseURL)
+	self.followRun(record)
 }
 
-func (self *Server) FetchJobPodLogs(podNamespace string, podName string, threadTs string) {
-	logs, podStatus, err := self.manager.GetPodLogs(podNamespace, podName)
-	log.Printf("podStatus = %s", podStatus)
+//followRun follows the Job of a run until its end, the run can be cancelled meanwhile.
+//Only the leader follows runs, other replicas leave them to be adopted by the leader.
+func (self *Server) followRun(record *JobHistory.Record) {
+	if !self.leadership.isLeader() {
+		self.runs.finish(record.ID)
+		return
+	}
+
+	runCtx, run := self.runs.start(record)
+	defer func() {
+		//A released run may already be followed again by this replica if it became the leader again.
+		if !run.isReleased() {
+			self.runs.finish(record.ID)
+		}
+	}()
+	ctx, cancel := withDeadline(runCtx, record)
+	defer cancel()
+
+	self.FetchJobPodLogs(ctx, run)
+	self.updateRunStatus(record)
+}
+
+//FetchJobPodLogs follows every attempt of the Job of a run, posting their logs in the run thread,
+//and records the outcome of the Job once it is Complete or Failed.
+func (self *Server) FetchJobPodLogs(ctx context.Context, run *activeRun) {
+	record := run.record
+	logsRedactor := self.newRedactor(record)
+	waitConfig := self.config.commandConfig(commandName(record)).Wait
+	var streamer *logStreamer
+	handlers := PodManager.JobHandlers{
+		WaitingFunc: waitConfig.waitingFunc(&self.manager),
+		OnAttemptStart: func(number int, pod *v1.Pod) {
+			if number > 1 {
+				record.PodName = pod.Name
+				self.saveRunRecord(record)
+				self.sendSlackMessageWithClient(record.ChannelID, ":repeat: Attempt "+strconv.Itoa(number)+" started with pod `"+pod.Name+"`", record.ThreadTs)
+			}
+			streamer = self.newLogStreamer(record)
+		},
+		OnUpdate: func(pod *v1.Pod) {
+			//Phase changes are always recorded, progress only pushes them to Slack along with the elapsed time.
+			if phase := string(pod.Status.Phase); phase != "" && phase != record.Phase {
+				record.Phase = phase
+				self.saveRunRecord(record)
+			}
+			if waitConfig.Progress == nil || *waitConfig.Progress {
+				self.updateRunStatus(record)
+			}
+		},
+		OnLine: func(line string) {
+			streamer.write(logsRedactor.redact(line))
+		},
+		OnRestart: func(restartCount int32, previousLogs string) {
+			if !streamer.close() {
+				self.postLogs(record, logsRedactor.redact(previousLogs))
+			}
+			self.sendSlackMessageWithClient(record.ChannelID, ":repeat: Container of pod `"+record.PodName+"` restarted ("+strconv.Itoa(int(restartCount))+" restarts)", record.ThreadTs)
+			streamer = self.newLogStreamer(record)
+		},
+		OnAttemptEnd: func(attempt PodManager.JobAttempt) {
+			if !streamer.close() && run.cancelledByUser() == "" && !run.isReleased() {
+				self.postLogs(record, logsRedactor.redact(attempt.Logs))
+			}
+			if attempt.Phase == JobHistory.PhaseFailed && !run.isReleased() {
+				message := ":x: Attempt " + strconv.Itoa(attempt.Number) + " with pod `" + attempt.PodName + "` failed"
+				if attempt.ExitCode != nil {
+					message += " with exit code " + strconv.Itoa(int(*attempt.ExitCode))
+				}
+				self.sendSlackMessageWithClient(record.ChannelID, message, record.ThreadTs)
+			}
+		},
+	}
+
+	result, err := self.manager.WaitForJob(ctx, record.Namespace, record.JobName, record.PodName, handlers)
+	logs := ""
+	if len(result.Attempts) > 0 {
+		lastAttempt := result.Attempts[len(result.Attempts)-1]
+		logs = logsRedactor.redact(lastAttempt.Logs)
+		record.ExitCode = lastAttempt.ExitCode
+	}
+	if run.isReleased() {
+		log.Printf("Job %s has been left to the new leader", record.JobName)
+		return
+	}
+	log.Printf("Job %s ended with status %s", record.JobName, result.Phase)
+
+	if cancelledBy := run.cancelledByUser(); cancelledBy != "" {
+		record.CancelledBy = cancelledBy
+		self.finishRecord(record, JobHistory.PhaseCancelled, logs)
+		self.notifyRequester(record, ":no_entry_sign: Job "+record.JobName+" has been cancelled by <@"+cancelledBy+">")
+		return
+	}
+
+	//The run may have been cancelled from another replica, which reports it.
+	if stored, err := self.history.Get(record.ID); err == nil && stored.CancelledBy != "" {
+		*record = *stored
+		return
+	}
+
+	if self.deadlineExceeded(ctx, record) {
+		diagnostics := self.diagnoseFailure(record)
+		record.FailureReason = "DeadlineExceeded"
+		self.finishRecord(record, JobHistory.PhaseFailed, logs)
+
+		message := ":hourglass: Job " + record.JobName + " has been killed for exceeding its deadline of " + (time.Duration(record.DeadlineSeconds) * time.Second).String()
+		self.notifyRequester(record, message)
+		self.postFailureDiagnostics(record, diagnostics, logsRedactor)
+		return
+	}
 
 	if err != nil {
-		self.sendSlackMessageWithClient(err.Error(), "")
+		diagnostics := self.diagnoseFailure(record)
+		var startErr *PodManager.PodStartError
+		if errors.As(err, &startErr) {
+			//The Job would keep its pod pending forever, it is deleted so nothing is left behind.
+			if deleteErr := self.manager.DeleteJob(record.Namespace, record.JobName); deleteErr != nil {
+				log.Printf("Error when deleting job %s which can't start : %s", record.JobName, deleteErr.Error())
+			}
+			record.FailureReason = startErr.Reason
+			err = errors.New(":x: Job " + record.JobName + " has been aborted and deleted, " + err.Error())
+		}
+		self.finishRecord(record, JobHistory.PhaseFailed, err.Error())
+		self.notifyRequester(record, err.Error())
+		self.postFailureDiagnostics(record, diagnostics, logsRedactor)
 		return
 	}
 
-	log.Printf("Sending back logs to slack channel")
-	self.sendSlackMessageWithClient("Job "+podName+" "+podStatus, threadTs)
-	self.sendSlackMessageWithClient(logs, threadTs)
+	var diagnostics *PodManager.PodDiagnostics
+	if result.Phase == JobHistory.PhaseFailed {
+		diagnostics = self.diagnoseFailure(record)
+		if record.FailureReason == "" {
+			record.FailureReason = result.Reason
+		}
+	}
+	self.finishRecord(record, result.Phase, logs)
+	self.notifyRequester(record, "Job "+record.JobName+" ended with status "+result.Phase)
+	self.postFailureDiagnostics(record, diagnostics, logsRedactor)
 }
 
 func (self *Server) handleSlackCommand() http.HandlerFunc {
@@ -105,66 +260,102 @@ func (self *Server) handleSlackCommand() http.HandlerFunc {
 			return
 		}
 
+		if err := verifier.Ensure(); err != nil {
+			log.Println("Error verifying slack request signature: ", err.Error())
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		self.updateAvgJobTime()
 
-		switch s.Command {
-		case self.config.SEED_COMMAND:
-			slackTextArguments := strings.Fields(s.Text)
-			err := r.ParseForm()
-			if err != nil {
-				SendSlackMessage("Error : "+err.Error(), w)
-				log.Println("[ERROR] " + err.Error())
-				return
-			}
+		handler, request, err := self.commands.Lookup(s.Command, strings.Fields(s.Text))
+		if err != nil {
+			SendSlackMessage(err.Error(), w)
+			return
+		}
+		request.UserID = s.UserID
+		request.UserName = s.UserName
+		request.ChannelID = s.ChannelID
+		request.ResponseURL = s.ResponseURL
 
-			if len(slackTextArguments) < 2 {
-				SendSlackMessage("You must at least specify a version and a seed name !", w)
-				return
-			}
+		if err := handler.Validate(request.Arguments); err != nil {
+			SendSlackMessage(err.Error()+"\n"+handler.Help(), w)
+			return
+		}
 
-			version := slackTextArguments[0]
-			if !self.isValidVersion(version) {
-				SendSlackMessage("You must specify a good version (eg. v.1.0.0) : "+version, w)
-				return
+		if runner, ok := handler.(CommandRunner); ok {
+			environment := ""
+			if targeter, ok := handler.(EnvironmentTargeter); ok {
+				if environment, err = targeter.TargetEnvironment(request); err != nil {
+					SendSlackMessage(err.Error(), w)
+					return
+				}
 			}
-
-			self.increaseSeedLaunched()
-			self.SubmitJobCreation(s.Command, slackTextArguments, w, r)
-		case self.config.MIGRATION_COMMAND:
-			slackTextArguments := strings.Fields(s.Text)
-			err := r.ParseForm()
-			if err != nil {
-				SendSlackMessage("Error : "+err.Error(), w)
-				log.Println("[ERROR] " + err.Error())
+			if err := self.authorize(request, environment); err != nil {
+				self.audit("command_denied", request.UserID, "`"+request.commandLine()+"` : "+err.Error())
+				SendSlackMessage(err.Error(), w)
 				return
 			}
-
-			if len(slackTextArguments) < 2 {
-				SendSlackMessage("You must at least specify a version and a migration name!", w)
+			if !self.inflight.start(func() { runner.Run(request) }) {
+				SendSlackMessage(restartingMessage, w)
 				return
 			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 
-			version := slackTextArguments[0]
-			if !self.isValidVersion(version) {
-				SendSlackMessage("You must specify a good version (eg. v.1.0.0) : "+version, w)
-				return
-			}
+		payload, err := handler.BuildJob(request)
+		if err != nil {
+			SendSlackMessage("An error occured while building your job : "+err.Error(), w)
+			log.Println("[ERROR] " + err.Error())
+			return
+		}
 
-			self.increaseMigrationLaunched()
-			self.SubmitJobCreation(s.Command, slackTextArguments, w, r)
+		if err := self.authorize(request, payload.Environment); err != nil {
+			self.audit("command_denied", request.UserID, "`"+request.commandLine()+"` : "+err.Error())
+			SendSlackMessage(err.Error(), w)
 			return
-		default:
-			SendSlackMessage("Current slack command is not implemented yet !", w)
+		}
+
+		if err := self.checkLock(payload); err != nil {
+			SendSlackMessage(err.Error(), w)
 			return
 		}
 
+		if self.requiresApproval(payload) {
+			if !self.inflight.start(func() { self.requestApproval(request, payload) }) {
+				SendSlackMessage(restartingMessage, w)
+				return
+			}
+			SendSlackMessage("`"+request.commandLine()+"` needs to be approved by someone else, asking for it in <#"+payload.AnswerChannel+">", w)
+			return
+		}
+
+		if !self.inflight.start(func() { self.SubmitJobCreation(request, payload) }) {
+			SendSlackMessage(restartingMessage, w)
+			return
+		}
+		SendSlackMessage("`"+request.commandLine()+"` is being launched, I'll keep you posted", w)
 	}
 }
 
 func New(listenPort int, podManager PodManager.PodManager) *Server {
 	appConfig := initConfig()
 	slackClient := slack.New(appConfig.SLACK_API_TOKEN)
-	return &Server{port: listenPort, manager: podManager, config: *appConfig, slackClient: *slackClient}
+	historyStore, err := JobHistory.New(appConfig.HISTORY, podManager.Client())
+	if err != nil {
+		log.Panicln("Error when opening job history : " + err.Error())
+	}
+
+	approvalStore, err := JobHistory.NewApprovalStore(appConfig.HISTORY, podManager.Client())
+	if err != nil {
+		log.Panicln("Error when opening approvals store : " + err.Error())
+	}
+
+	server := &Server{port: listenPort, manager: podManager, config: *appConfig, slackClient: *slackClient, history: historyStore, approvals: approvalStore}
+	server.registerCommands()
+	server.registerActions()
+	return server
 }
 
 func Listen(manager PodManager.PodManager) {
@@ -180,14 +371,50 @@ func Listen(manager PodManager.PodManager) {
 	}
 	server := New(appPort, manager)
 	server.recordMetrics()
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	electionStopped := make(chan struct{})
+	if server.config.HIGH_AVAILABILITY.Enabled {
+		go func() {
+			server.runLeaderElection(electionCtx)
+			close(electionStopped)
+		}()
+	} else {
+		server.leadership.set(true)
+		close(electionStopped)
+		go server.recoverRuns()
+		go server.sweepApprovals(electionCtx)
+	}
 
 	http.HandleFunc("/", server.handleSlackCommand())
 	http.HandleFunc("/events", server.handleSlackEvent())
+	http.HandleFunc("/interactivity", server.handleSlackInteraction())
 	http.HandleFunc("/healthz", healthz)
 	http.Handle("/metrics", promhttp.Handler())
 
-	log.Println("Server started on port " + appPortStr)
-	if err := http.ListenAndServe(":"+appPortStr, nil); err != nil {
-		panic(err.Error())
+	httpServer := &http.Server{Addr: ":" + appPortStr}
+	serveErrors := make(chan error, 1)
+	go func() {
+		log.Println("Server started on port " + appPortStr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErrors <- err
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	var serveErr error
+	select {
+	case received := <-signals:
+		log.Printf("Received %s", received)
+	case serveErr = <-serveErrors:
+		log.Printf("Error when serving on port %s : %s", appPortStr, serveErr.Error())
+	}
+
+	server.shutdown(httpServer)
+	//The Lease is released so another replica takes over right away.
+	stopElection()
+	<-electionStopped
+	if serveErr != nil {
+		log.Fatalln("Stopped after the http server failure : " + serveErr.Error())
 	}
 }
@@ -0,0 +1,140 @@
+/**
+ * File              : recovery.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+//recoveryPodTimeout is how long a recovered run waits for a pod of its Job.
+const recoveryPodTimeout = time.Minute
+
+//recoverRuns resumes following the runs whose Job has not been reported yet, after the bot restarted.
+//Jobs are found through the run labels set by CreateJob, in the namespaces of every environment.
+func (self *Server) recoverRuns() {
+	for _, namespace := range self.config.namespaces() {
+		jobs, err := self.manager.ListRunJobs(namespace)
+		if err != nil {
+			log.Printf("Error when listing jobs to recover in namespace %s : %s", namespace, err.Error())
+			continue
+		}
+
+		for index := range jobs {
+			self.adoptRun(&jobs[index], ":arrows_counterclockwise: I restarted, following job "+jobs[index].Name+" again")
+		}
+	}
+}
+
+//adoptRun follows the run of a Job if it is neither ended nor followed yet.
+//@args notice: posted in the run thread once its Job is followed again, nothing is posted when empty.
+func (self *Server) adoptRun(job *batchv1.Job, notice string) {
+	runID := PodManager.GetRunInfo(job).ID
+	if runID == "" || !self.runs.reserve(runID) {
+		return
+	}
+
+	record := self.recoveredRecord(job)
+	if record == nil {
+		self.runs.finish(runID)
+		return
+	}
+	log.Printf("Following run %s of job %s", record.ID, record.JobName)
+	if !self.inflight.start(func() { self.resumeRun(record, notice) }) {
+		self.runs.finish(runID)
+	}
+}
+
+//recoveredRecord returns the record of the run of a Job to resume, nil if it does not need to be.
+func (self *Server) recoveredRecord(job *batchv1.Job) *JobHistory.Record {
+	runInfo := PodManager.GetRunInfo(job)
+	if runInfo.ChannelID == "" {
+		return nil
+	}
+
+	record, err := self.history.Get(runInfo.ID)
+	switch {
+	case err == nil:
+		if record.FinishedAt != nil {
+			return nil
+		}
+	case err == JobHistory.ErrNotFound:
+		if PodManager.IsJobFinished(job) {
+			return nil
+		}
+		//The history has been lost along with the bot (eg. file backend), the run is rebuilt from the Job.
+		record = &JobHistory.Record{
+			ID:          runInfo.ID,
+			RequesterID: runInfo.RequesterID,
+			Command:     runInfo.Command,
+			Environment: runInfo.Environment,
+			Namespace:   job.Namespace,
+			Phase:       JobHistory.PhaseCreating,
+			StartedAt:   job.CreationTimestamp.Time,
+			ChannelID:   runInfo.ChannelID,
+			ThreadTs:    runInfo.ThreadTs,
+		}
+		if len(job.Spec.Template.Spec.Containers) > 0 {
+			record.Image = job.Spec.Template.Spec.Containers[0].Image
+		}
+		if job.Spec.ActiveDeadlineSeconds != nil {
+			record.DeadlineSeconds = *job.Spec.ActiveDeadlineSeconds
+		}
+	default:
+		log.Printf("Error when fetching run %s to recover : %s", runInfo.ID, err.Error())
+		return nil
+	}
+
+	record.JobName = job.Name
+	record.Namespace = job.Namespace
+	return record
+}
+
+//resumeRun follows the Job of an adopted run and reports its end in the original thread.
+func (self *Server) resumeRun(record *JobHistory.Record, notice string) {
+	ctx, cancel := context.WithTimeout(context.Background(), recoveryPodTimeout)
+	defer cancel()
+
+	pod, err := self.manager.WaitForJobPod(ctx, record.Namespace, record.JobName, nil)
+	if err != nil {
+		self.runs.finish(record.ID)
+		log.Printf("Error when finding the pod of recovered run %s : %s", record.ID, err.Error())
+		self.finishRecord(record, JobHistory.PhaseFailed, "")
+		self.updateRunStatus(record)
+		self.sendSlackMessageWithClient(record.ChannelID, ":warning: I restarted and could not find the pod of job "+record.JobName+" anymore : "+err.Error(), record.ThreadTs)
+		return
+	}
+
+	record.PodName = pod.Name
+	self.saveRecord(record)
+	if notice != "" {
+		self.sendSlackMessageWithClient(record.ChannelID, notice, record.ThreadTs)
+	}
+	self.followRun(record)
+}
+
+//namespaces returns the namespaces of every environment, without duplicates.
+func (self *ServerConfig) namespaces() []string {
+	unique := map[string]bool{}
+	for _, environment := range self.ENVIRONMENTS {
+		unique[environment.Namespace] = true
+	}
+
+	namespaces := make([]string, 0, len(unique))
+	for namespace := range unique {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
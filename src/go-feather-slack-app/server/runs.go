@@ -0,0 +1,344 @@
+/**
+ * File              : runs.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	"github.com/slack-go/slack"
+)
+
+const (
+	cancelRunActionID = "cancel_run"
+	retryRunActionID  = "retry_run"
+)
+
+//activeRun is a run currently followed by this server.
+type activeRun struct {
+	record      *JobHistory.Record
+	cancel      context.CancelFunc
+	mutex       sync.Mutex
+	cancelledBy string
+	released    bool
+}
+
+//runRegistry holds the runs followed by this server by run ID,
+//and the ones about to be followed so they are not adopted twice.
+type runRegistry struct {
+	mutex    sync.Mutex
+	runs     map[string]*activeRun
+	reserved map[string]bool
+}
+
+//reserve marks a run as about to be followed.
+//@returns bool: false if the run is already followed or reserved.
+func (self *runRegistry) reserve(id string) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if _, ok := self.runs[id]; ok || self.reserved[id] {
+		return false
+	}
+	if self.reserved == nil {
+		self.reserved = make(map[string]bool)
+	}
+	self.reserved[id] = true
+	return true
+}
+
+//start registers the run and returns the context to use while following it.
+func (self *runRegistry) start(record *JobHistory.Record) (context.Context, *activeRun) {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &activeRun{record: record, cancel: cancel}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.runs == nil {
+		self.runs = make(map[string]*activeRun)
+	}
+	self.runs[record.ID] = run
+	delete(self.reserved, record.ID)
+	return ctx, run
+}
+
+func (self *runRegistry) finish(id string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	delete(self.reserved, id)
+	if run, ok := self.runs[id]; ok {
+		run.cancel()
+		delete(self.runs, id)
+	}
+}
+
+//releaseAll stops following every run without reporting their end, another replica takes them over.
+//@returns []*JobHistory.Record: the records of the released runs.
+func (self *runRegistry) releaseAll() []*JobHistory.Record {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	records := []*JobHistory.Record{}
+	for id, run := range self.runs {
+		run.mutex.Lock()
+		run.released = true
+		run.mutex.Unlock()
+		run.cancel()
+		delete(self.runs, id)
+		records = append(records, run.record)
+	}
+	self.reserved = nil
+	return records
+}
+
+func (self *runRegistry) get(id string) *activeRun {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.runs[id]
+}
+
+//setCancelledBy records who cancelled the run, it is set before deleting the Job so its deletion is not seen as a failure.
+func (self *activeRun) setCancelledBy(userID string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.cancelledBy = userID
+}
+
+//isReleased tells whether the run has been left to another replica.
+func (self *activeRun) isReleased() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.released
+}
+
+//cancelledByUser returns the user who cancelled the run, empty if it has not been cancelled.
+func (self *activeRun) cancelledByUser() string {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.cancelledBy
+}
+
+//cancelRun deletes the Job of a run and stops following it.
+//@args runID: the ID of the run to cancel
+//@args request: the request of the user cancelling the run, used for authorization
+//@returns error if the run can't be cancelled.
+func (self *Server) cancelRun(runID string, request *CommandRequest) error {
+	record, err := self.history.Get(runID)
+	if err == JobHistory.ErrNotFound {
+		return errors.New("Run " + runID + " does not exist")
+	} else if err != nil {
+		return err
+	}
+
+	if record.FinishedAt != nil {
+		return errors.New("Run " + runID + " has already ended with status " + record.Phase)
+	}
+	if record.JobName == "" {
+		return errors.New("Run " + runID + " has no Job yet, try again in a few seconds")
+	}
+
+	if err := self.authorize(request, record.Environment); err != nil {
+		self.audit("cancel_denied", request.UserID, fmt.Sprintf("run %s : %s", runID, err.Error()))
+		return err
+	}
+
+	run := self.runs.get(runID)
+	if run != nil {
+		run.setCancelledBy(request.UserID)
+	} else {
+		//The run may be followed by the leader, it is told through the history that its Job deletion is a cancellation.
+		record.CancelledBy = request.UserID
+		self.saveRecord(record)
+	}
+
+	if err := self.manager.DeleteJob(record.Namespace, record.JobName); err != nil {
+		if run != nil {
+			run.setCancelledBy("")
+		} else {
+			record.CancelledBy = ""
+			self.saveRecord(record)
+		}
+		return errors.New("I could not delete Job " + record.JobName + " : " + err.Error())
+	}
+	self.audit("run_cancelled", request.UserID, fmt.Sprintf("run %s `%s` on %s", runID, record.Command, record.Environment))
+
+	if run != nil {
+		run.cancel()
+		return nil
+	}
+
+	//The run is not followed by this server, its end is recorded right away.
+	self.finishRecord(record, JobHistory.PhaseCancelled, record.LogExcerpt)
+	self.updateRunStatus(record)
+	self.sendSlackMessageWithClient(record.ChannelID, ":no_entry_sign: Job "+record.JobName+" has been cancelled by <@"+request.UserID+">", record.ThreadTs)
+	return nil
+}
+
+//runEnvironment returns the environment a run has been launched on.
+func (self *Server) runEnvironment(runID string) (string, error) {
+	record, err := self.history.Get(runID)
+	if err == JobHistory.ErrNotFound {
+		return "", errors.New("Run " + runID + " does not exist")
+	} else if err != nil {
+		return "", err
+	}
+	return record.Environment, nil
+}
+
+//cancelHandler cancels a running job (eg. /migration cancel <run-id>).
+type cancelHandler struct {
+	server  *Server
+	command string
+}
+
+func (self *cancelHandler) Help() string {
+	return "`" + self.command + " cancel <run-id>` cancels a running job"
+}
+
+func (self *cancelHandler) Validate(arguments []string) error {
+	if len(arguments) != 1 {
+		return errors.New("You must specify the run to cancel")
+	}
+	return nil
+}
+
+func (self *cancelHandler) BuildJob(request *CommandRequest) (*JobCreationPayload, error) {
+	return nil, errors.New("`" + self.command + " cancel` does not launch any job")
+}
+
+func (self *cancelHandler) TargetEnvironment(request *CommandRequest) (string, error) {
+	return self.server.runEnvironment(request.Arguments[0])
+}
+
+func (self *cancelHandler) Run(request *CommandRequest) {
+	runID := request.Arguments[0]
+	if err := self.server.cancelRun(runID, request); err != nil {
+		self.server.sendSlackResponse(err.Error(), request.ResponseURL)
+		return
+	}
+	self.server.sendSlackResponse("Run "+runID+" is being cancelled", request.ResponseURL)
+}
+
+//handleCancelRunAction cancels the run whose Cancel button has been clicked.
+func (self *Server) handleCancelRunAction(callback *slack.InteractionCallback, action *slack.BlockAction) {
+	request := &CommandRequest{Subcommand: "cancel", UserID: callback.User.ID, UserName: callback.User.Name, ChannelID: callback.Channel.ID, ResponseURL: callback.ResponseURL}
+	if record, err := self.history.Get(action.Value); err == nil {
+		request.Command = commandName(record)
+	}
+
+	if err := self.cancelRun(action.Value, request); err != nil {
+		log.Printf("Error when cancelling run %s : %s", action.Value, err.Error())
+		self.sendSlackResponse(err.Error(), callback.ResponseURL)
+	}
+}
+
+//retryRun launches again the Job of a finished run with the exact same parameters.
+//@args runID: the ID of the run to retry
+//@args request: the request of the user retrying the run, used for authorization
+//@returns error if the run can't be retried.
+func (self *Server) retryRun(runID string, request *CommandRequest) error {
+	record, err := self.history.Get(runID)
+	if err == JobHistory.ErrNotFound {
+		return errors.New("Run " + runID + " does not exist")
+	} else if err != nil {
+		return err
+	}
+
+	if record.FinishedAt == nil {
+		return errors.New("Run " + runID + " is still running")
+	}
+
+	payload := &JobCreationPayload{}
+	if err := json.Unmarshal(record.Payload, payload); err != nil || len(record.Payload) == 0 {
+		return errors.New("Run " + runID + " parameters have not been recorded, it can't be retried")
+	}
+	payload.JobName = newJobName()
+	payload.RetryOf = record.ID
+
+	command := commandName(record)
+	retryRequest := &CommandRequest{
+		Command:     command,
+		Subcommand:  "retry",
+		Text:        strings.TrimSpace(strings.TrimPrefix(record.Command, command)),
+		UserID:      request.UserID,
+		UserName:    request.UserName,
+		ChannelID:   request.ChannelID,
+		ResponseURL: request.ResponseURL,
+	}
+
+	if err := self.authorize(retryRequest, payload.Environment); err != nil {
+		self.audit("retry_denied", request.UserID, fmt.Sprintf("run %s : %s", runID, err.Error()))
+		return err
+	}
+	if err := self.checkLock(payload); err != nil {
+		return err
+	}
+
+	started := self.inflight.start(func() {
+		if self.requiresApproval(payload) {
+			self.requestApproval(retryRequest, payload)
+		} else {
+			self.SubmitJobCreation(retryRequest, payload)
+		}
+	})
+	if !started {
+		return errors.New(restartingMessage)
+	}
+	self.sendSlackMessageWithClient(record.ChannelID, ":repeat: Retry asked by <@"+request.UserID+">", record.ThreadTs)
+	return nil
+}
+
+//retryHandler launches again a previous run (eg. /migration retry <run-id>).
+type retryHandler struct {
+	server  *Server
+	command string
+}
+
+func (self *retryHandler) Help() string {
+	return "`" + self.command + " retry <run-id>` launches again a previous run with the same parameters"
+}
+
+func (self *retryHandler) Validate(arguments []string) error {
+	if len(arguments) != 1 {
+		return errors.New("You must specify the run to retry")
+	}
+	return nil
+}
+
+func (self *retryHandler) BuildJob(request *CommandRequest) (*JobCreationPayload, error) {
+	return nil, errors.New("`" + self.command + " retry` does not build any job by itself")
+}
+
+func (self *retryHandler) TargetEnvironment(request *CommandRequest) (string, error) {
+	return self.server.runEnvironment(request.Arguments[0])
+}
+
+func (self *retryHandler) Run(request *CommandRequest) {
+	runID := request.Arguments[0]
+	if err := self.server.retryRun(runID, request); err != nil {
+		self.server.sendSlackResponse(err.Error(), request.ResponseURL)
+		return
+	}
+	self.server.sendSlackResponse("Run "+runID+" is being retried", request.ResponseURL)
+}
+
+//handleRetryRunAction retries the run whose Retry button has been clicked.
+func (self *Server) handleRetryRunAction(callback *slack.InteractionCallback, action *slack.BlockAction) {
+	request := &CommandRequest{UserID: callback.User.ID, UserName: callback.User.Name, ChannelID: callback.Channel.ID, ResponseURL: callback.ResponseURL}
+	if err := self.retryRun(action.Value, request); err != nil {
+		log.Printf("Error when retrying run %s : %s", action.Value, err.Error())
+		self.sendSlackResponse(err.Error(), callback.ResponseURL)
+	}
+}
@@ -0,0 +1,89 @@
+/**
+ * File              : shutdown.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//defaultShutdownTimeout stays under the 30 seconds Kubernetes waits before killing a terminating pod.
+const defaultShutdownTimeout = 25 * time.Second
+
+//restartingMessage answers the requests received while the server is shutting down.
+const restartingMessage = "I'm restarting, please try again in a minute"
+
+//inflightWork tracks the work run in background, waited for before shutting down.
+type inflightWork struct {
+	mutex    sync.Mutex
+	group    sync.WaitGroup
+	draining bool
+}
+
+//start runs work in its own goroutine.
+//@returns bool: false if the server is shutting down, work is then not run.
+func (self *inflightWork) start(work func()) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.draining {
+		return false
+	}
+	self.group.Add(1)
+	go func() {
+		defer self.group.Done()
+		work()
+	}()
+	return true
+}
+
+//drain refuses any new work and waits for the running one until ctx is done.
+//@returns bool: true if every work has ended in time.
+func (self *inflightWork) drain(ctx context.Context) bool {
+	self.mutex.Lock()
+	self.draining = true
+	self.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		self.group.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+//shutdown stops accepting requests and waits for the in-flight Slack posts and watchers, up to SHUTDOWN_TIMEOUT.
+//The runs still followed once it is over are released and a notice is posted in their thread,
+//they are followed again once the bot is back (or by the new leader).
+func (self *Server) shutdown(httpServer *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), self.config.SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight work", self.config.SHUTDOWN_TIMEOUT)
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("Error when stopping http server : %s", err.Error())
+	}
+	if self.inflight.drain(ctx) {
+		log.Println("Every in-flight work has ended")
+		return
+	}
+
+	for _, record := range self.runs.releaseAll() {
+		log.Printf("Job %s is still running, leaving it", record.JobName)
+		self.sendSlackMessageWithClient(record.ChannelID, ":warning: Bot restarting, job "+record.JobName+" is still running, it will be followed again once I'm back", record.ThreadTs)
+	}
+}
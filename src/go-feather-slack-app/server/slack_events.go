@@ -2,7 +2,7 @@
  * File              : slack_events.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 23.01.2021
- * Last Modified Date: 09.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package server
@@ -83,7 +83,7 @@ func (self *Server) handleSlackEvent() http.HandlerFunc {
 			case *slackevents.AppMentionEvent:
 				log.Println("Found the mention")
 				textMessage := generateDefaultAnswerMention()
-				threadTs, err := self.sendSlackMessageWithClient(textMessage, "")
+				threadTs, err := self.sendSlackMessageWithClient(ev.Channel, textMessage, "")
 				if err != nil {
 					log.Printf("Error when posting message on slack thread_ts=%s and err=%s", threadTs, err.Error())
 				}
@@ -0,0 +1,102 @@
+/**
+ * File              : status.go
+ * Author            : Alexandre Saison <alexandre.saison@inarix.com>
+ * Date              : 17.10.2026
+ * Last Modified Date: 17.10.2026
+ * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
+ */
+package server
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
+	"github.com/slack-go/slack"
+)
+
+//postRunStatus posts the status message of a run, it becomes the root of the run thread.
+//@returns (string, error): the message timestamp used as thread_ts.
+func (self *Server) postRunStatus(record *JobHistory.Record) (string, error) {
+	_, threadTs, err := self.slackClient.PostMessage(record.ChannelID, slack.MsgOptionText(runStatusText(record), false), slack.MsgOptionBlocks(runStatusBlocks(record)...))
+	return threadTs, err
+}
+
+//updateRunStatus edits the status message of a run with its current state.
+func (self *Server) updateRunStatus(record *JobHistory.Record) {
+	if record.ThreadTs == "" {
+		return
+	}
+
+	if _, _, _, err := self.slackClient.UpdateMessage(record.ChannelID, record.ThreadTs, slack.MsgOptionText(runStatusText(record), false), slack.MsgOptionBlocks(runStatusBlocks(record)...)); err != nil {
+		log.Printf("Error when updating status message of run %s : %s", record.ID, err.Error())
+	}
+}
+
+//runStatusText is the notification text of the status message.
+func runStatusText(record *JobHistory.Record) string {
+	return fmt.Sprintf("%s %s %s on %s : %s", phaseEmoji(record.Phase), strings.Title(record.Kind), record.MigrationName, record.Environment, record.Phase)
+}
+
+//runStatusBlocks renders the status message of a run,
+//with a Cancel button while it is running and a Retry button once it has failed or has been cancelled.
+func runStatusBlocks(record *JobHistory.Record) []slack.Block {
+	title := fmt.Sprintf("%s *%s `%s`* on *%s* / *%s* : *%s*", phaseEmoji(record.Phase), strings.Title(record.Kind), record.MigrationName, record.Environment, record.Service, record.Phase)
+	if record.CancelledBy != "" {
+		title += " by <@" + record.CancelledBy + ">"
+	}
+
+	elapsed := "*Elapsed*\n" + time.Since(record.StartedAt).Round(time.Second).String()
+	if record.DeadlineSeconds > 0 {
+		elapsed += " (deadline " + (time.Duration(record.DeadlineSeconds) * time.Second).String() + ")"
+	}
+	if record.FinishedAt != nil {
+		elapsed = "*Duration*\n" + record.FinishedAt.Sub(record.StartedAt).Round(time.Second).String()
+		if record.ExitCode != nil {
+			elapsed += " (exit code " + strconv.Itoa(int(*record.ExitCode)) + ")"
+		}
+	}
+
+	run := "*Run*\n`" + record.ID + "`"
+	if record.RetryOf != "" {
+		run += " (retry of `" + record.RetryOf + "`)"
+	}
+
+	fields := []*slack.TextBlockObject{
+		slack.NewTextBlockObject(slack.MarkdownType, "*Requester*\n<@"+record.RequesterID+">", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Image*\n`"+record.Image+"`", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Job*\n`"+record.JobName+"`", false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, run, false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, elapsed, false, false),
+	}
+	if record.FailureReason != "" {
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, "*Reason*\n"+record.FailureReason, false, false))
+	}
+
+	updatedAt := time.Now()
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, title, false, false), fields, nil),
+		slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Last update <!date^%d^{time_secs}|%s>", updatedAt.Unix(), updatedAt.Format(time.RFC3339)), false, false)),
+	}
+
+	if record.FinishedAt == nil {
+		confirmation := slack.NewConfirmationBlockObject(
+			slack.NewTextBlockObject(slack.PlainTextType, "Cancel this job?", false, false),
+			slack.NewTextBlockObject(slack.MarkdownType, "Job `"+record.JobName+"` will be deleted with its pods.", false, false),
+			slack.NewTextBlockObject(slack.PlainTextType, "Cancel the job", false, false),
+			slack.NewTextBlockObject(slack.PlainTextType, "Keep it running", false, false),
+		)
+		cancelButton := slack.NewButtonBlockElement(cancelRunActionID, record.ID, slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false))
+		cancelButton.Confirm = confirmation
+		return append(blocks, slack.NewActionBlock("run-"+record.ID, cancelButton.WithStyle(slack.StyleDanger)))
+	}
+
+	if record.Phase == JobHistory.PhaseFailed || record.Phase == JobHistory.PhaseCancelled {
+		retryButton := slack.NewButtonBlockElement(retryRunActionID, record.ID, slack.NewTextBlockObject(slack.PlainTextType, "Retry", false, false))
+		return append(blocks, slack.NewActionBlock("run-"+record.ID, retryButton))
+	}
+	return blocks
+}
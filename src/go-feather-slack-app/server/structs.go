@@ -2,13 +2,17 @@
  * File              : structs.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 21.12.2020
- * Last Modified Date: 04.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 
 package server
 
 import (
+	"regexp"
+	"time"
+
+	JobHistory "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/history"
 	PodManager "github.com/saisona/go-feather-slack-app/src/go-feather-slack-app/manager"
 	"github.com/slack-go/slack"
 )
@@ -23,6 +27,17 @@ type ServerConfig struct {
 	SEED_COMMAND                 string
 	SEQUELIZE_MIGRATION_ENV_NAME string
 	SEQUELIZE_SEED_ENV_NAME      string
+	ENVIRONMENTS                 map[string]*EnvironmentConfig
+	DEFAULT_ENVIRONMENT          string
+	SERVICES                     map[string]*ServiceConfig
+	DEFAULT_SERVICE              string
+	COMMANDS                     map[string]*CommandConfig
+	AUTHORIZATION                AuthorizationConfig
+	HISTORY                      JobHistory.Config
+	LOGS                         LogsConfig
+	LOGS_REDACTION_PATTERNS      []*regexp.Regexp
+	HIGH_AVAILABILITY            HighAvailabilityConfig
+	SHUTDOWN_TIMEOUT             time.Duration
 }
 
 type Server struct {
@@ -30,14 +45,34 @@ type Server struct {
 	manager     PodManager.PodManager
 	config      ServerConfig
 	slackClient slack.Client
+	commands    *CommandRegistry
+	userGroups  userGroupCache
+	actions     map[string]ActionHandler
+	approvals   JobHistory.ApprovalStore
+	history     JobHistory.Store
+	runs        runRegistry
+	leadership  leadership
+	inflight    inflightWork
 }
 
 type JobCreationPayload struct {
+	Kind            string            `json:"kind"`
+	Environment     string            `json:"environment"`
+	Service         string            `json:"service"`
+	Version         string            `json:"version"`
+	Name            string            `json:"name"`
 	Namespace       string            `json:"namespace"`
+	ServiceAccount  string            `json:"serviceAccount"`
+	AnswerChannel   string            `json:"answerChannel"`
 	JobName         string            `json:"jobName"`
 	ConfigMapsNames []string          `json:"configMapsNames"`
 	EnvVariablesMap map[string]string `json:"envVariables"`
 	DockerImage     string            `json:"dockerImage"`
+	RetryOf         string            `json:"retryOf,omitempty"`
+	DeadlineSeconds int64             `json:"deadlineSeconds,omitempty"`
+	BackoffLimit    *int32            `json:"backoffLimit,omitempty"`
+	TTLSeconds      *int32            `json:"ttlSecondsAfterFinished,omitempty"`
+	RestartPolicy   string            `json:"restartPolicy,omitempty"`
 }
 
 type SlackApiEventPayload struct {
@@ -2,12 +2,14 @@
  * File              : utils.go
  * Author            : Alexandre Saison <alexandre.saison@inarix.com>
  * Date              : 04.01.2021
- * Last Modified Date: 18.02.2021
+ * Last Modified Date: 17.10.2026
  * Last Modified By  : Alexandre Saison <alexandre.saison@inarix.com>
  */
 package server
 
 import (
+	cryptoRand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,6 +20,8 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/slack-go/slack"
 )
@@ -72,10 +76,11 @@ func SendSlackMessage(message string, w http.ResponseWriter) {
 
 // Send Slack message using the API call
 //@used: used to send async message
+//@args channelID:, is the channel to post into
 //@args message:, is the message to send
-//@args threadTs:, is the message to send
+//@args threadTs:, is the thread to answer into (empty to start a new one)
 //@returns: (string, error) where string is the thread_ts.
-func (self *Server) sendSlackMessageWithClient(message string, threadTs string) (string, error) {
+func (self *Server) sendSlackMessageWithClient(channelID string, message string, threadTs string) (string, error) {
 	var Options slack.MsgOption
 
 	OptionMessage := slack.MsgOptionText(message, false)
@@ -87,19 +92,66 @@ func (self *Server) sendSlackMessageWithClient(message string, threadTs string)
 		Options = slack.MsgOptionCompose(OptionMessage)
 	}
 
-	_, thread_ts, err := self.slackClient.PostMessage(self.config.SLACK_ANSWER_CHANNEL_ID, Options)
+	_, thread_ts, err := self.slackClient.PostMessage(channelID, Options)
 	if err != nil {
 		return "", err
 	}
 	return thread_ts, nil
 }
 
+// Send Slack message to a slash command response_url
+//@used: to answer a slash command once the 3 seconds acknowledgement window is over
+//@args message: is the message to send
+//@args responseURL: is the response_url given along with the slash command
+func (self *Server) sendSlackResponse(message string, responseURL string) {
+	if responseURL == "" {
+		return
+	}
+
+	OptionResponse := slack.MsgOptionResponseURL(responseURL, slack.ResponseTypeEphemeral)
+	OptionMessage := slack.MsgOptionText(message, false)
+	if _, _, err := self.slackClient.PostMessage("", OptionResponse, OptionMessage); err != nil {
+		log.Printf("Error when answering to response_url : %s", err.Error())
+	}
+}
+
+// Send Block Kit message to a slash command or interaction response_url
+//@args text: is the fallback text of the message
+//@args blocks: are the Block Kit blocks of the message
+//@args responseURL: is the response_url given along with the slash command or the interaction
+//@args replaceOriginal: replaces the message the interaction comes from instead of posting a new one
+func (self *Server) sendSlackResponseBlocks(text string, blocks []slack.Block, responseURL string, replaceOriginal bool) {
+	OptionResponse := slack.MsgOptionResponseURL(responseURL, slack.ResponseTypeEphemeral)
+	if replaceOriginal {
+		OptionResponse = slack.MsgOptionReplaceOriginal(responseURL)
+	}
+
+	OptionMessage := slack.MsgOptionCompose(slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...))
+	if _, _, err := self.slackClient.PostMessage("", OptionResponse, OptionMessage); err != nil {
+		log.Printf("Error when answering to response_url : %s", err.Error())
+	}
+}
+
 func generateDefaultAnswerMention() string {
 	possibleAnswers := []string{"Hello there !", "What can I do for you!", "Work work work everyday, everyday the same work!", "Oh I hope this time it'll work!", "When can I'll take a break?"}
 	indexAnswer := rand.Intn(5)
 	return possibleAnswers[indexAnswer]
 }
 
+//newIdentifier returns a short unique identifier, used to reference approvals and runs from Slack.
+func newIdentifier() string {
+	suffix := make([]byte, 2)
+	if _, err := cryptoRand.Read(suffix); err != nil {
+		log.Printf("Error when generating random identifier : %s", err.Error())
+	}
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + hex.EncodeToString(suffix)
+}
+
+//newJobName returns the name prefix of a new Job.
+func newJobName() string {
+	return "go-feather-slack-app-" + strconv.Itoa(int(time.Now().Unix()))
+}
+
 func (self *Server) isValidVersion(payload string) bool {
 	version := payload
 	versionRegex, _ := regexp.Compile("v[0-9]+\\.[0-9]+\\.[0-9]+")
@@ -112,14 +164,49 @@ func initConfig() *ServerConfig {
 	SLACK_SIGNING_SECRET := os.Getenv("SLACK_SIGNING_SECRET")
 	SLACK_ANSWER_CHANNEL_ID := os.Getenv("SLACK_ANSWER_CHANNEL_ID")
 
+	CONFIG_FILE := os.Getenv("APP_CONFIG_FILE")
 	DOCKER_IMAGE := os.Getenv("APP_DOCKER_IMAGE")
 	MIGRATION_COMMAND := os.Getenv("APP_MIGRATION_COMMAND")
 	SEED_COMMAND := os.Getenv("APP_SEED_COMMAND")
 	SEQUELIZE_MIGRATION_ENV_NAME := os.Getenv("APP_SEQUELIZE_MIGRATION_ENV_NAME")
 	SEQUELIZE_SEED_ENV_NAME := os.Getenv("APP_SEQUELIZE_SEED_ENV_NAME")
 
-	if SLACK_API_TOKEN == "" || DOCKER_IMAGE == "" || SEQUELIZE_MIGRATION_ENV_NAME == "" || SEQUELIZE_SEED_ENV_NAME == "" || SLACK_SIGNING_SECRET == "" || SLACK_ANSWER_CHANNEL_ID == "" {
-		log.Panicln(errors.New("One of [APP_DOCKER_IMAGE, SLACK_API_TOKEN, APP_SEQUELIZE_SEED_ENV_NAME, APP_SEQUELIZE_MIGRATION_ENV_NAME, SLACK_SIGNING_SECRET, SLACK_ANSWER_CHANNEL_ID] environment variables is missing").Error())
+	if SLACK_API_TOKEN == "" || SLACK_SIGNING_SECRET == "" {
+		log.Panicln(errors.New("One of [SLACK_API_TOKEN, SLACK_SIGNING_SECRET] environment variables is missing").Error())
+	}
+
+	fileConfig := &FileConfig{}
+	if CONFIG_FILE != "" {
+		var err error
+		if fileConfig, err = loadConfigFile(CONFIG_FILE); err != nil {
+			log.Panicln(err.Error())
+		}
+	}
+
+	if err := fileConfig.resolveEnvironments(DOCKER_IMAGE, SLACK_ANSWER_CHANNEL_ID); err != nil {
+		log.Panicln(err.Error())
+	}
+
+	if err := fileConfig.resolveServices(SEQUELIZE_MIGRATION_ENV_NAME, SEQUELIZE_SEED_ENV_NAME); err != nil {
+		log.Panicln(err.Error())
+	}
+
+	if err := fileConfig.resolveCommands(); err != nil {
+		log.Panicln(err.Error())
+	}
+
+	if err := fileConfig.HighAvailability.validate(fileConfig.History); err != nil {
+		log.Panicln(err.Error())
+	}
+
+	LOGS_REDACTION_PATTERNS, err := compileRedactionPatterns(fileConfig.Logs.Redact)
+	if err != nil {
+		log.Panicln(err.Error())
+	}
+
+	SHUTDOWN_TIMEOUT := fileConfig.ShutdownTimeout.Duration
+	if SHUTDOWN_TIMEOUT <= 0 {
+		SHUTDOWN_TIMEOUT = defaultShutdownTimeout
 	}
 
 	if MIGRATION_COMMAND == "" {
@@ -129,7 +216,7 @@ func initConfig() *ServerConfig {
 
 	if SEED_COMMAND == "" {
 		log.Println("WARNING: You didn't specified any APP_SEED_COMMAND, default /seed will be used")
-		MIGRATION_COMMAND = "/seed"
+		SEED_COMMAND = "/seed"
 	}
 
 	return &ServerConfig{
@@ -141,5 +228,16 @@ func initConfig() *ServerConfig {
 		SEED_COMMAND:                 SEED_COMMAND,
 		SEQUELIZE_MIGRATION_ENV_NAME: SEQUELIZE_MIGRATION_ENV_NAME,
 		SEQUELIZE_SEED_ENV_NAME:      SEQUELIZE_SEED_ENV_NAME,
+		ENVIRONMENTS:                 fileConfig.Environments,
+		DEFAULT_ENVIRONMENT:          fileConfig.DefaultEnvironment,
+		SERVICES:                     fileConfig.Services,
+		DEFAULT_SERVICE:              fileConfig.DefaultService,
+		COMMANDS:                     fileConfig.Commands,
+		AUTHORIZATION:                fileConfig.Authorization,
+		HISTORY:                      fileConfig.History,
+		LOGS:                         fileConfig.Logs,
+		LOGS_REDACTION_PATTERNS:      LOGS_REDACTION_PATTERNS,
+		HIGH_AVAILABILITY:            fileConfig.HighAvailability,
+		SHUTDOWN_TIMEOUT:             SHUTDOWN_TIMEOUT,
 	}
 }